@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	uuid "github.com/google/uuid"
+	config "github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving"
+	logger "github.com/ndau/go-logger"
+)
+
+// serve - Listen for PingSource events and serve the query and admin APIs
+func serve(ctx context.Context, args []string, store *config.Store, repo dal.Repo, log logger.Logger) int {
+	cf := store.Load()
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if !autoMigrate(ctx, cf, repo, log) {
+		return 1
+	}
+
+	kn, err := serving.NewKnClient(cf, log)
+	if err != nil {
+		log.Errorf("Failed to initialize knative client: %v", err)
+		return 1
+		//panic(erKn)
+	}
+
+	if err := kn.Run(ctx, repo, store); err != nil {
+		log.Errorf("Failed to serve: %v", err)
+		return 1
+	}
+
+	log.Info("quit...")
+	return 0
+}
+
+// runOnce - Process one event, the way a PingSource would, and exit with its status
+func runOnce(ctx context.Context, args []string, store *config.Store, repo dal.Repo, log logger.Logger) int {
+	cf := store.Load()
+	var data models.Data
+	flags := flag.NewFlagSet("run-once", flag.ContinueOnError)
+	flags.StringVar(&data.Network, "network", "", "ndau network, the configured one when empty")
+	flags.StringVar(&data.NodeAPI, "node-api", "", "URL of the node API, the first configured node answering when empty")
+	flags.IntVar(&data.Limit, "limit", 0, "accounts read per page of the account list, run.page_size when zero")
+	flags.StringVar(&data.StartAfterKey, "start-after", "", "address to start the account list after, run.start_after_key when empty")
+	flags.StringVar(&data.Policy, "policy", "", "allocation policy, the configured default when empty")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if resolved := data.WithDefaults(cf); resolved.Network == "" || resolved.NodeAPI == "" {
+		log.Error("--network and --node-api are required when no node is configured")
+		return 2
+	}
+
+	if !autoMigrate(ctx, cf, repo, log) {
+		return 1
+	}
+
+	kn, err := serving.NewKnClient(cf, log)
+	if err != nil {
+		log.Errorf("Failed to initialize knative client: %v", err)
+		return 1
+	}
+
+	trackingNumber := uuid.New().String()
+	if err := kn.ProcessEvent(context.WithValue(ctx, "tracking_number", trackingNumber), &data, repo, cf); err != nil {
+		log.Errorf("%s | Failed to process the run: %v", trackingNumber, err)
+		return 1
+	}
+	return 0
+}
+
+// migrate - Apply the schema migrations and exit
+func migrate(ctx context.Context, args []string, store *config.Store, repo dal.Repo, log logger.Logger) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := repo.Migrate(ctx); err != nil {
+		log.Errorf("Failed to migrate the database schema: %v", err)
+		return 1
+	}
+	log.Info("Database schema is up to date")
+	return 0
+}
+
+// report - Print the allocation of a stored snapshot
+func report(ctx context.Context, args []string, store *config.Store, repo dal.Repo, log logger.Logger) int {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	snapshotID := flags.Int64("snapshot", 0, "id of the snapshot")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *snapshotID == 0 {
+		log.Error("--snapshot is required")
+		return 2
+	}
+
+	snapshot, err := repo.GetSnapshot(ctx, *snapshotID)
+	if err != nil {
+		log.Errorf("Failed to read snapshot %d: %v", *snapshotID, err)
+		return 1
+	}
+	accounts, err := repo.ListSnapshotAccounts(ctx, *snapshotID)
+	if err != nil {
+		log.Errorf("Failed to read accounts of snapshot %d: %v", *snapshotID, err)
+		return 1
+	}
+
+	// The largest voting power first
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return accounts[i].EffectiveVotes > accounts[j].EffectiveVotes
+	})
+
+	if *asJSON {
+		err = json.NewEncoder(os.Stdout).Encode(struct {
+			Snapshot *models.Snapshot
+			Accounts []models.SnapshotAccount
+		}{snapshot, accounts})
+	} else {
+		err = printAllocation(os.Stdout, snapshot, accounts)
+	}
+	if err != nil {
+		log.Errorf("Failed to print snapshot %d: %v", *snapshotID, err)
+		return 1
+	}
+	return 0
+}
+
+// recompute - Rerun the allocation of a stored snapshot with a chosen policy
+func recompute(ctx context.Context, args []string, store *config.Store, repo dal.Repo, log logger.Logger) int {
+	cf := store.Load()
+	flags := flag.NewFlagSet("recompute", flag.ContinueOnError)
+	snapshotID := flags.Int64("snapshot", 0, "id of the snapshot")
+	policy := flags.String("policy", "", "allocation policy, the configured default when empty")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *snapshotID == 0 {
+		log.Error("--snapshot is required")
+		return 2
+	}
+
+	kn, err := serving.NewKnClient(cf, log)
+	if err != nil {
+		log.Errorf("Failed to initialize knative client: %v", err)
+		return 1
+	}
+	snapshot, err := kn.Recompute(context.WithValue(ctx, "tracking_number", uuid.New().String()), repo, cf, *snapshotID, *policy)
+	if err != nil {
+		log.Errorf("Failed to recompute snapshot %d: %v", *snapshotID, err)
+		return 1
+	}
+	log.Infof("Snapshot %d recomputed as snapshot %d", *snapshotID, snapshot.ID)
+	return 0
+}
+
+// autoMigrate - Apply the schema migrations unless disabled, false when they failed
+func autoMigrate(ctx context.Context, cf *models.Config, repo dal.Repo, log logger.Logger) bool {
+	if !cf.AutoMigrate {
+		return true
+	}
+
+	log.Info("Migrating database schema...")
+	if err := repo.Migrate(ctx); err != nil {
+		log.Errorf("Failed to migrate the database schema: %v", err)
+		return false
+	}
+	return true
+}
+
+// printAllocation - A summary of the snapshot followed by one line per account
+func printAllocation(out io.Writer, snapshot *models.Snapshot, accounts []models.SnapshotAccount) error {
+	var votes, effective float64
+	seats := 0
+	for _, account := range accounts {
+		votes += account.Votes
+		effective += account.EffectiveVotes
+		if account.Eligible && !account.CurrencySeatDate.Before(models.SeatedSince) {
+			seats++
+		}
+	}
+
+	parent := "-"
+	if snapshot.ParentID != nil {
+		parent = fmt.Sprint(*snapshot.ParentID)
+	}
+	fmt.Fprintf(out, "Snapshot %d (parent %s), policy %s, %s on %s at %s\n", snapshot.ID, parent, snapshot.Policy,
+		snapshot.Status, snapshot.Network, snapshot.StartedAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Fprintf(out, "%d accounts, %d seats, %d ndau on chain, %.2f votes, %.2f effective votes\n\n",
+		len(accounts), seats, snapshot.ChainTotalNdau, votes, effective)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "ADDRESS\tCLASS\tELIGIBLE\tBALANCE\tSEAT DATE\tVOTES\tEFFECTIVE VOTES\t")
+	for _, account := range accounts {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%s\t%.2f\t%.2f\t\n", account.Address, account.Class, account.Eligible,
+			account.Balance, account.CurrencySeatDate.Format("2006-01-02"), account.Votes, account.EffectiveVotes)
+	}
+	return w.Flush()
+}
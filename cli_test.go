@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+	logger "github.com/ndau/go-logger"
+)
+
+var seatDate = time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// newStore - The default configuration without any node nor pause between batches
+func newStore() *config.Store {
+	cfg := models.DefaultConfig()
+	cfg.Run.BatchPauseMs = 0
+	return config.NewStore(&cfg)
+}
+
+// completedSnapshot - A repository holding a completed snapshot of two accounts
+func completedSnapshot(t *testing.T) (*dal.Memory, *models.Snapshot) {
+	t.Helper()
+
+	repo := dal.NewMemory()
+	snapshot := &models.Snapshot{TrackingNumber: "cli", Policy: models.DefaultPolicyName, Status: models.SnapshotRunning, Network: "testnet", StartedAt: seatDate}
+	if err := repo.CreateSnapshot(context.Background(), snapshot); err != nil {
+		t.Fatalf("CreateSnapshot() = %v", err)
+	}
+	accounts := []models.SnapshotAccount{
+		{Address: "ndaaone", Balance: 1000, CurrencySeatDate: seatDate, Class: "regular", Eligible: true, Votes: 6000000, EffectiveVotes: 6000000},
+		{Address: "ndaatwo", Balance: 2000, CurrencySeatDate: seatDate, Class: models.AccountClassExcluded},
+	}
+	if err := repo.SaveSnapshotAccounts(context.Background(), snapshot.ID, accounts); err != nil {
+		t.Fatalf("SaveSnapshotAccounts() = %v", err)
+	}
+	snapshot.Status = models.SnapshotCompleted
+	snapshot.ChainTotalNdau = 3000
+	if err := repo.FinishSnapshot(context.Background(), snapshot); err != nil {
+		t.Fatalf("FinishSnapshot() = %v", err)
+	}
+	return repo, snapshot
+}
+
+func TestRunRejectsAnUnknownCommand(t *testing.T) {
+	if code := run([]string{"unknown"}); code != 2 {
+		t.Errorf("run(unknown) = %d, want 2", code)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	log := &logger.NoopLogger{}
+	if code := migrate(context.Background(), nil, newStore(), dal.NewMemory(), log); code != 0 {
+		t.Errorf("migrate() = %d, want 0", code)
+	}
+	if code := migrate(context.Background(), []string{"--unknown"}, newStore(), dal.NewMemory(), log); code != 2 {
+		t.Errorf("migrate(--unknown) = %d, want 2", code)
+	}
+}
+
+func TestReport(t *testing.T) {
+	log := &logger.NoopLogger{}
+	repo, snapshot := completedSnapshot(t)
+
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"--snapshot", "1"}, 0},
+		{[]string{"--snapshot", "1", "--json"}, 0},
+		{nil, 2},
+		{[]string{"--snapshot", "x"}, 2},
+		{[]string{"--snapshot", "42"}, 1},
+	} {
+		if code := report(context.Background(), tt.args, newStore(), repo, log); code != tt.want {
+			t.Errorf("report(%v) = %d, want %d", tt.args, code, tt.want)
+		}
+	}
+
+	var out bytes.Buffer
+	accounts, _ := repo.ListSnapshotAccounts(context.Background(), snapshot.ID)
+	if err := printAllocation(&out, snapshot, accounts); err != nil {
+		t.Fatalf("printAllocation() = %v", err)
+	}
+	for _, want := range []string{
+		"Snapshot 1 (parent -), policy default, completed on testnet",
+		"2 accounts, 1 seats, 3000 ndau on chain, 6000000.00 votes, 6000000.00 effective votes",
+		"ndaatwo",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printAllocation() = %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestRecompute(t *testing.T) {
+	log := &logger.NoopLogger{}
+	repo, _ := completedSnapshot(t)
+
+	if code := recompute(context.Background(), []string{"--snapshot", "1"}, newStore(), repo, log); code != 0 {
+		t.Fatalf("recompute() = %d, want 0", code)
+	}
+	derived, err := repo.GetSnapshot(context.Background(), 2)
+	if err != nil || derived.ParentID == nil || *derived.ParentID != 1 || derived.Status != models.SnapshotCompleted {
+		t.Errorf("derived snapshot = %+v, %v, want a completed snapshot of snapshot 1", derived, err)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{nil, 2},
+		{[]string{"--snapshot", "1", "--policy", "missing"}, 1},
+		{[]string{"--snapshot", "42"}, 1},
+	} {
+		if code := recompute(context.Background(), tt.args, newStore(), repo, log); code != tt.want {
+			t.Errorf("recompute(%v) = %d, want %d", tt.args, code, tt.want)
+		}
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	log := &logger.NoopLogger{}
+
+	// Neither requested nor configured
+	if code := runOnce(context.Background(), nil, newStore(), dal.NewMemory(), log); code != 2 {
+		t.Errorf("runOnce() without a node = %d, want 2", code)
+	}
+
+	fixture, err := nodetest.LoadFixture("serving/testdata/node/small.json")
+	if err != nil {
+		t.Fatalf("LoadFixture() = %v", err)
+	}
+	node := nodetest.NewServer(fixture)
+	defer node.Close()
+
+	repo := dal.NewMemory()
+	args := []string{"--network", "testnet", "--node-api", node.URL}
+	if code := runOnce(context.Background(), args, newStore(), repo, log); code != 0 {
+		t.Fatalf("runOnce(%v) = %d, want 0", args, code)
+	}
+	if accounts, err := repo.ListAccount(context.Background()); err != nil || len(accounts) != 7 {
+		t.Errorf("ListAccount() after run-once = %d accounts, %v, want 7", len(accounts), err)
+	}
+}
@@ -2,7 +2,10 @@ package configuration
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/mitchellh/mapstructure"
@@ -12,40 +15,196 @@ import (
 )
 
 const (
-	dbURL = "NDAU_CONNECTION_STRING"
+	dbURL       = "NDAU_CONNECTION_STRING"
+	autoMigrate = "NDAU_AUTO_MIGRATE"
+	network     = "NDAU_NETWORK"
+	nodeAPI     = "NDAU_NODE_API"
+	adminToken  = "NDAU_ADMIN_TOKEN"
+	adminTokens = "NDAU_ADMIN_TOKENS"
+	dbPassword  = "NDAU_DB_PASSWORD"
 )
 
-// LoadConfig ...
+// LoadConfig - Apply the configuration file and the secret over the defaults. Every problem found
+// is reported at once in a models.ValidationError.
 func LoadConfig(ctx context.Context, cfg configure.Config, log logger.Logger) (*models.Config, error) {
-	var ret models.Config
+	ret := models.DefaultConfig()
+	problems := &models.ValidationError{}
+
 	log.Info("Get config from local file")
 	envCfg := cfg.GetStringMap("env")
-	err := mapstructure.Decode(envCfg, &ret)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		// Values of the secret often arrive as strings
+		WeaklyTypedInput: true,
+		Result:           &ret,
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if err := decoder.Decode(envCfg); err != nil {
+		problems.Add("%v", err)
+	}
+
+	if err := loadEnvConfig(envCfg, &ret); err != nil {
+		problems.Add("%v", err)
+	}
+
+	if err := ret.Validate(); err != nil {
+		var invalid *models.ValidationError
+		if errors.As(err, &invalid) {
+			problems.Problems = append(problems.Problems, invalid.Problems...)
+		} else {
+			problems.Add("%v", err)
+		}
 	}
 
-	if err := loadEnvConfig(cfg.GetStringMap("env"), &ret); err != nil {
-		panic(err)
+	if err := problems.Err(); err != nil {
+		return nil, err
 	}
 	return &ret, nil
 }
 
-func loadEnvConfig(dm map[string]interface{}, cfg *models.Config) error {
-	//DB access
-	val, ok := dm[dbURL]
-	if !ok {
-		val, ok = dm[strings.ToLower(dbURL)]
-		if !ok {
-			return fmt.Errorf("no field '%s' in the secret", dbURL)
+// loadEnvConfig - The NDAU_* keys of the secret override the sections of the configuration
+func loadEnvConfig(dm map[string]interface{}, cfg *models.Config) (err error) {
+	//DB access, either a connection string or the database section
+	if val, err := optionalString(dm, dbURL); err != nil {
+		return err
+	} else if val != "" {
+		cfg.ConnectionString = val
+	}
+	if err := loadPassword(&cfg.Database); err != nil {
+		return err
+	}
+
+	// Schema migrations, on by default
+	if cfg.AutoMigrate, err = optionalBool(dm, autoMigrate, cfg.AutoMigrate); err != nil {
+		return err
+	}
+
+	// Default node of the runs, also used to verify votes
+	if val, err := optionalString(dm, network); err != nil {
+		return err
+	} else if val != "" {
+		cfg.Network = val
+	}
+	if val, err := optionalString(dm, nodeAPI); err != nil {
+		return err
+	} else if val != "" {
+		cfg.NodeAPI = val
+	}
+	cfg.Nodes = nodeList(cfg.NodeAPI, cfg.Nodes)
+	if len(cfg.Nodes) > 0 {
+		cfg.NodeAPI = cfg.Nodes[0]
+	}
+
+	// Admin bearer tokens, kept in the secret rather than in the admin section. A shared token would not
+	// tell the operators apart in the audit trail.
+	if token, err := optionalString(dm, adminToken); err != nil {
+		return err
+	} else if token != "" {
+		return fmt.Errorf("%s is no longer supported, give each operator a token in %s", adminToken, adminTokens)
+	}
+	if val, err := optionalString(dm, adminTokens); err != nil {
+		return err
+	} else if val != "" {
+		if cfg.Admin.Tokens, err = parseAdminTokens(val); err != nil {
+			return err
 		}
 	}
 
-	db, ok := val.(string)
-	if !ok {
-		return fmt.Errorf("field '%s' in the secret is not a string but a '%T'", dbURL, val)
+	return nil
+}
+
+// parseAdminTokens - The operators of a "name:token,name:token" list
+func parseAdminTokens(val string) (map[string]string, error) {
+	tokens := map[string]string{}
+	for _, entry := range strings.Split(val, ",") {
+		name, token, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || name == "" || token == "" {
+			return nil, fmt.Errorf("%s must list name:token pairs separated by commas", adminTokens)
+		}
+		if _, ok := tokens[name]; ok {
+			return nil, fmt.Errorf("%s lists operator '%s' twice", adminTokens, name)
+		}
+		tokens[name] = token
 	}
-	cfg.ConnectionString = db
+	return tokens, nil
+}
 
+// nodeList - The preferred node first, followed by the other nodes without duplicates
+func nodeList(preferred string, nodes []string) []string {
+	list := []string{}
+	seen := map[string]bool{}
+	for _, node := range append([]string{preferred}, nodes...) {
+		node = strings.TrimRight(node, "/")
+		if node == "" || seen[node] {
+			continue
+		}
+		seen[node] = true
+		list = append(list, node)
+	}
+	return list
+}
+
+// loadPassword - Keep the database password out of the connection string: read it from the
+// password file, else from the NDAU_DB_PASSWORD environment variable, unless set inline
+func loadPassword(db *models.DatabaseConfig) error {
+	if db.Password != "" {
+		return nil
+	}
+
+	if db.PasswordFile != "" {
+		content, err := os.ReadFile(db.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed reading the database password file: %v", err)
+		}
+		db.Password = strings.TrimRight(string(content), "\r\n")
+		return nil
+	}
+
+	db.Password = os.Getenv(dbPassword)
 	return nil
 }
+
+// optionalString - Read an optional string key, empty when missing
+func optionalString(dm map[string]interface{}, key string) (string, error) {
+	val, ok := lookup(dm, key)
+	if !ok {
+		return "", nil
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("field '%s' in the secret is not a string but a '%T'", key, val)
+	}
+	return str, nil
+}
+
+// optionalBool - Read an optional boolean key, given either as a bool or a string
+func optionalBool(dm map[string]interface{}, key string, def bool) (bool, error) {
+	val, ok := lookup(dm, key)
+	if !ok {
+		return def, nil
+	}
+
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return def, fmt.Errorf("field '%s' in the secret is not a boolean: %v", key, err)
+		}
+		return b, nil
+	default:
+		return def, fmt.Errorf("field '%s' in the secret is not a boolean but a '%T'", key, val)
+	}
+}
+
+// lookup - Find an optional key in upper or lower case
+func lookup(dm map[string]interface{}, key string) (interface{}, bool) {
+	if val, ok := dm[key]; ok {
+		return val, true
+	}
+	val, ok := dm[strings.ToLower(key)]
+	return val, ok
+}
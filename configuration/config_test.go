@@ -0,0 +1,164 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/models"
+	configure "github.com/ndau/go-config"
+	logger "github.com/ndau/go-logger"
+)
+
+func TestLoadAdminTokens(t *testing.T) {
+	cfg := models.DefaultConfig()
+	dm := map[string]interface{}{
+		// Keys of the secret arrive lower cased
+		"ndau_admin_tokens": "alice:alice-0123456789abcdef, bob:bob:with-a-colon-0123",
+	}
+	if err := loadEnvConfig(dm, &cfg); err != nil {
+		t.Fatalf("loadEnvConfig() = %v", err)
+	}
+	want := map[string]string{"alice": "alice-0123456789abcdef", "bob": "bob:with-a-colon-0123"}
+	if len(cfg.Admin.Tokens) != len(want) {
+		t.Fatalf("admin tokens = %v, want %v", cfg.Admin.Tokens, want)
+	}
+	for name, token := range want {
+		if cfg.Admin.Tokens[name] != token {
+			t.Errorf("token of %s = %q, want %q", name, cfg.Admin.Tokens[name], token)
+		}
+	}
+}
+
+func TestLoadAdminTokensRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		dm   map[string]interface{}
+		err  string
+	}{
+		{"the shared token", map[string]interface{}{adminToken: "shared-0123456789abcdef"}, "no longer supported"},
+		{"a token without name", map[string]interface{}{adminTokens: "alice-0123456789abcdef"}, "name:token pairs"},
+		{"an empty token", map[string]interface{}{adminTokens: "alice:"}, "name:token pairs"},
+		{"an operator twice", map[string]interface{}{adminTokens: "alice:one-0123456789abcdef,alice:two-0123456789abcdef"}, "operator 'alice' twice"},
+		{"not a string", map[string]interface{}{adminTokens: 42}, "is not a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := models.DefaultConfig()
+			if err := loadEnvConfig(tt.dm, &cfg); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("loadEnvConfig() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadPassword(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-the-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(dbPassword, "from-the-env")
+
+	tests := []struct {
+		name string
+		db   models.DatabaseConfig
+		want string
+	}{
+		{"inline first", models.DatabaseConfig{Password: "inline", PasswordFile: file}, "inline"},
+		{"the file", models.DatabaseConfig{PasswordFile: file}, "from-the-file"},
+		{"the environment", models.DatabaseConfig{}, "from-the-env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := loadPassword(&tt.db); err != nil {
+				t.Fatalf("loadPassword() = %v", err)
+			}
+			if tt.db.Password != tt.want {
+				t.Errorf("password = %q, want %q", tt.db.Password, tt.want)
+			}
+		})
+	}
+
+	missing := models.DatabaseConfig{PasswordFile: filepath.Join(t.TempDir(), "missing")}
+	if err := loadPassword(&missing); err == nil || !strings.Contains(err.Error(), "password file") {
+		t.Errorf("loadPassword() = %v, want an error on the missing file", err)
+	}
+}
+
+// writeConfig - A configuration file read by go-config, as mounted from the ConfigMap
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed writing %s: %v", path, err)
+	}
+}
+
+// loadFile - LoadConfig of a configuration file
+func loadFile(t *testing.T, content string) (*models.Config, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, content)
+	cfg, err := configure.New(path)
+	if err != nil {
+		t.Fatalf("configure.New() = %v", err)
+	}
+	return LoadConfig(context.Background(), cfg, &logger.NoopLogger{})
+}
+
+func TestLoadConfig(t *testing.T) {
+	cf, err := loadFile(t, `
+env:
+  ndau_connection_string: postgres://voting@db/voting
+  ndau_node_api: https://mainnet-0.ndau.tech/
+  port: "9090"
+  network: mainnet
+  nodes:
+    - https://mainnet-1.ndau.tech
+    - https://mainnet-0.ndau.tech
+  run:
+    batch_size: 50
+  database:
+    max_open_conns: 10
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig() = %v", err)
+	}
+
+	if cf.ConnectionString != "postgres://voting@db/voting" || cf.Port != 9090 || cf.Run.BatchSize != 50 || cf.Database.MaxOpenConns != 10 {
+		t.Errorf("LoadConfig() = %+v, want the file over the defaults", cf)
+	}
+	// Untouched settings keep their default
+	if def := models.DefaultConfig(); cf.Run.PageSize != def.Run.PageSize || cf.Database.Driver != def.Database.Driver {
+		t.Errorf("LoadConfig() = %+v, want the defaults of the other settings", cf)
+	}
+	// The node of the secret comes first, without duplicates
+	want := []string{"https://mainnet-0.ndau.tech", "https://mainnet-1.ndau.tech"}
+	if cf.NodeAPI != want[0] || strings.Join(cf.Nodes, ",") != strings.Join(want, ",") {
+		t.Errorf("nodes = %s %v, want %v", cf.NodeAPI, cf.Nodes, want)
+	}
+}
+
+func TestLoadConfigReportsEveryProblem(t *testing.T) {
+	_, err := loadFile(t, `
+env:
+  port: not-a-port
+  log_level: trace
+  ndau_auto_migrate: maybe
+  run:
+    page_size: 0
+`)
+	var invalid *models.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("LoadConfig() = %v, want a ValidationError", err)
+	}
+	for _, want := range []string{"port", "is not a boolean", "a connection string or a database host is required", "log_level", "run.page_size"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadConfig() = %v, want a problem with %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package configuration
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ndau/dao-voting-setup/models"
+	configure "github.com/ndau/go-config"
+	logger "github.com/ndau/go-logger"
+)
+
+// Store - The current configuration, replaced as a whole when the configuration file changes
+type Store struct {
+	current atomic.Pointer[models.Config]
+
+	mu        sync.Mutex
+	listeners []func(*models.Config)
+}
+
+// NewStore - A store holding the configuration loaded at startup
+func NewStore(cf *models.Config) *Store {
+	s := &Store{}
+	s.current.Store(cf)
+	return s
+}
+
+// Load - The current configuration, which must not be modified
+func (s *Store) Load() *models.Config {
+	return s.current.Load()
+}
+
+// OnChange - Call f with every configuration reloaded from now on
+func (s *Store) OnChange(f func(*models.Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, f)
+}
+
+// update - Replace the configuration and notify the listeners
+func (s *Store) update(cf *models.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current.Store(cf)
+	for _, f := range s.listeners {
+		f(cf)
+	}
+}
+
+// watchable - The configuration, as returned by go-config, which reads the file again when it changes
+type watchable interface {
+	configure.Config
+	OnConfigChange(run func(in fsnotify.Event))
+}
+
+// Watch - Reload the store whenever the configuration file changes, e.g. a ConfigMap update.
+// An invalid configuration is rejected as a whole, and changes to the settings only read at startup
+// are logged as ignored until the next restart.
+func Watch(ctx context.Context, cfg watchable, store *Store, log logger.Logger) {
+	cfg.OnConfigChange(func(e fsnotify.Event) {
+		log.Infof("Configuration file %s changed, reloading", e.Name)
+		next, err := LoadConfig(ctx, cfg, log)
+		if err != nil {
+			log.Errorf("Keeping the current configuration: %v", err)
+			return
+		}
+
+		for _, key := range keepRestartOnly(store.Load(), next) {
+			log.Warnf("Ignoring the change of '%s' until the next restart", key)
+		}
+		store.update(next)
+		log.Infof("Reloaded configuration: %v", next)
+	})
+}
+
+// keepRestartOnly - Copy the settings applied at startup only from the current configuration,
+// and return the keys whose change is ignored
+func keepRestartOnly(current, next *models.Config) []string {
+	ignored := []string{}
+	keep := func(key string, cur, nxt interface{}) {
+		if reflect.DeepEqual(cur, reflect.ValueOf(nxt).Elem().Interface()) {
+			return
+		}
+		ignored = append(ignored, key)
+		reflect.ValueOf(nxt).Elem().Set(reflect.ValueOf(cur))
+	}
+
+	keep(dbURL, current.ConnectionString, &next.ConnectionString)
+	keep("database", current.Database, &next.Database)
+	keep("auto_migrate", current.AutoMigrate, &next.AutoMigrate)
+	keep("port", current.Port, &next.Port)
+	keep("features.query_api", current.Features.QueryAPI, &next.Features.QueryAPI)
+	keep("admin", current.Admin, &next.Admin)
+	keep("tracing", current.Tracing, &next.Tracing)
+
+	return ignored
+}
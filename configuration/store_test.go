@@ -0,0 +1,130 @@
+package configuration
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+	configure "github.com/ndau/go-config"
+	logger "github.com/ndau/go-logger"
+)
+
+func TestStore(t *testing.T) {
+	first := models.DefaultConfig()
+	store := NewStore(&first)
+	if store.Load() != &first {
+		t.Fatal("Load() is not the configuration of the store")
+	}
+
+	notified := []*models.Config{}
+	store.OnChange(func(cf *models.Config) { notified = append(notified, cf) })
+	second := models.DefaultConfig()
+	store.update(&second)
+
+	if store.Load() != &second {
+		t.Error("Load() is not the updated configuration")
+	}
+	if len(notified) != 1 || notified[0] != &second {
+		t.Errorf("listeners got %v, want the updated configuration once", notified)
+	}
+}
+
+func TestKeepRestartOnly(t *testing.T) {
+	current := models.DefaultConfig()
+	current.ConnectionString = "host=db"
+	current.Database.MaxOpenConns = 10
+
+	next := current
+	// Runtime settings
+	next.LogLevel = "debug"
+	next.Run.RequestsPerSecond = 5
+	next.Nodes = []string{"https://mainnet-1.ndau.tech"}
+	next.Exclusions = []models.Exclusion{{Address: "ndaexcluded"}}
+	// Settings read at startup
+	next.ConnectionString = "host=other"
+	next.Database.MaxOpenConns = 20
+	next.Port = 9090
+	next.Admin.Port = 9443
+	next.Tracing.Enabled = true
+	next.Features.QueryAPI = false
+
+	ignored := keepRestartOnly(&current, &next)
+	sort.Strings(ignored)
+	want := []string{dbURL, "admin", "database", "features.query_api", "port", "tracing"}
+	sort.Strings(want)
+	if strings.Join(ignored, ",") != strings.Join(want, ",") {
+		t.Errorf("ignored = %v, want %v", ignored, want)
+	}
+
+	if next.ConnectionString != "host=db" || next.Database.MaxOpenConns != 10 || next.Port != current.Port ||
+		next.Admin.Port != current.Admin.Port || next.Tracing.Enabled || !next.Features.QueryAPI {
+		t.Errorf("next = %+v, want the startup settings of the current configuration", next)
+	}
+	if next.LogLevel != "debug" || next.Run.RequestsPerSecond != 5 || len(next.Nodes) != 1 || len(next.Exclusions) != 1 {
+		t.Errorf("next = %+v, want its runtime settings", next)
+	}
+
+	if ignored := keepRestartOnly(&current, &current); len(ignored) != 0 {
+		t.Errorf("ignored = %v, want nothing without a change", ignored)
+	}
+}
+
+// waitFor - The next configuration sent on changes, failing after a few seconds
+func waitFor(t *testing.T, changes <-chan *models.Config) *models.Config {
+	t.Helper()
+
+	select {
+	case cf := <-changes:
+		return cf
+	case <-time.After(5 * time.Second):
+		t.Fatal("the configuration was not reloaded")
+		return nil
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "env:\n  ndau_connection_string: host=db\n  log_level: info\n")
+	cfg, err := configure.New(path)
+	if err != nil {
+		t.Fatalf("configure.New() = %v", err)
+	}
+	cf, err := LoadConfig(context.Background(), cfg, &logger.NoopLogger{})
+	if err != nil {
+		t.Fatalf("LoadConfig() = %v", err)
+	}
+
+	store := NewStore(cf)
+	changes := make(chan *models.Config, 10)
+	store.OnChange(func(cf *models.Config) { changes <- cf })
+	Watch(context.Background(), cfg, store, &logger.NoopLogger{})
+
+	// A runtime setting is applied, the connection string waits for the restart
+	writeConfig(t, path, "env:\n  ndau_connection_string: host=other\n  log_level: debug\n  run:\n    requests_per_second: 5\n")
+	next := waitFor(t, changes)
+	for next.LogLevel != "debug" {
+		// The file may be seen before it is completely written
+		next = waitFor(t, changes)
+	}
+	if next.Run.RequestsPerSecond != 5 || next.ConnectionString != "host=db" {
+		t.Errorf("reloaded %+v, want the new rate and the startup connection string", next)
+	}
+	if store.Load() != next {
+		t.Error("Load() is not the reloaded configuration")
+	}
+
+	// An invalid configuration is rejected as a whole
+	writeConfig(t, path, "env:\n  ndau_connection_string: host=db\n  log_level: trace\n  run:\n    requests_per_second: 50\n")
+	select {
+	case cf := <-changes:
+		t.Errorf("reloaded the invalid configuration %+v", cf)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if store.Load() != next {
+		t.Error("Load() is not the last valid configuration")
+	}
+}
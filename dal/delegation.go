@@ -0,0 +1,119 @@
+package dal
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// SetDelegation - Delegate the voting power of an account, replacing its previous delegate
+func (db *Db) SetDelegation(ctx context.Context, delegator, delegate string) error {
+	defer observeWrite("set_delegation")()
+
+	if delegator == delegate {
+		return errors.New("an account cannot delegate to itself")
+	}
+
+	delegation := models.Delegation{
+		Delegator: delegator,
+		Delegate:  delegate,
+		CreatedAt: time.Now(),
+	}
+	return db.retry(ctx, "set_delegation", func() error {
+		return db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "delegator"}},
+			DoUpdates: clause.AssignmentColumns([]string{"delegate", "created_at"}),
+		}).Create(&delegation).Error
+	})
+}
+
+// RevokeDelegation - Give the voting power back to the delegator
+func (db *Db) RevokeDelegation(ctx context.Context, delegator string) error {
+	defer observeWrite("revoke_delegation")()
+
+	res := db.Client.WithContext(ctx).Where("delegator = ?", delegator).Delete(&models.Delegation{})
+	if res.Error != nil {
+		return errors.Wrapf(res.Error, "failed revoking the delegation of '%s'", delegator)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDelegations - Read all delegations
+func (db *Db) ListDelegations(ctx context.Context) ([]models.Delegation, error) {
+	delegations := []models.Delegation{}
+	if err := db.retry(ctx, "list_delegations", func() error {
+		return db.Client.WithContext(ctx).Order("delegator asc").Find(&delegations).Error
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed reading from the delegations table")
+	}
+
+	return delegations, nil
+}
+
+// voteWeightBatch - Accounts read per query when weighting the votes
+const voteWeightBatch = 1000
+
+// voteWeights - Voting power of each voter of a proposal, see models.Delegations.ResolveVoter: its own
+// power plus the power of the accounts that did not vote and whose delegation chain ends with it.
+// Only the voters and the delegators are read from the accounts table.
+func (db *Db) voteWeights(tx *gorm.DB, proposalId int64) ([]models.Vote, map[string]float64, error) {
+	votes := []models.Vote{}
+	if err := tx.Where("proposal_id = ?", proposalId).Order("id asc").Find(&votes).Error; err != nil {
+		return nil, nil, errors.Wrapf(err, "failed reading votes of proposal '%d'", proposalId)
+	}
+
+	list := []models.Delegation{}
+	if err := tx.Find(&list).Error; err != nil {
+		return nil, nil, errors.Wrap(err, "failed reading from the delegations table")
+	}
+	delegations := models.NewDelegations(list)
+
+	voted := map[string]struct{}{}
+	for _, vote := range votes {
+		voted[vote.UserAddress] = struct{}{}
+	}
+
+	// The voter carrying the power of each account that counts
+	voters := map[string]string{}
+	for address := range voted {
+		voters[address] = address
+	}
+	for delegator := range delegations {
+		if voter, ok := delegations.ResolveVoter(delegator, voted, models.MaxDelegationDepth); ok {
+			voters[delegator] = voter
+		}
+	}
+	addresses := make([]string, 0, len(voters))
+	for address := range voters {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	weights := map[string]float64{}
+	for start := 0; start < len(addresses); start += voteWeightBatch {
+		end := start + voteWeightBatch
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		accounts := []models.VotingSetup{}
+		if err := tx.Select("address", "votes").Where("address IN ? AND votes > 0", addresses[start:end]).Find(&accounts).Error; err != nil {
+			return nil, nil, errors.Wrap(err, "failed reading voting power")
+		}
+		for _, account := range accounts {
+			weights[voters[account.Address]] += account.Votes
+		}
+	}
+
+	return votes, weights, nil
+}
@@ -0,0 +1,240 @@
+package dal
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// ErrInvalidConnectionString is returned when the database settings cannot be turned into a DSN
+var ErrInvalidConnectionString = errors.New("invalid connection string")
+
+// dsnKeys - Leading keys of a DSN, in this order, the others follow sorted
+var dsnKeys = []string{"host", "port", "user", "password", "dbname"}
+
+// DSN - The libpq key=value DSN of the database. The connection string, a postgres:// URL or a DSN,
+// comes first and the fields of the database section fill in or override its settings.
+func DSN(cfg *models.Config) (string, error) {
+	params := map[string]string{}
+	if cfg.ConnectionString != "" {
+		var err error
+		if params, err = parseConnectionString(cfg.ConnectionString); err != nil {
+			return "", err
+		}
+	}
+
+	db := cfg.Database
+	for key, val := range map[string]string{
+		"host":             db.Host,
+		"user":             db.User,
+		"password":         db.Password,
+		"dbname":           db.Name,
+		"sslmode":          db.SSLMode,
+		"sslrootcert":      db.SSLRootCert,
+		"application_name": db.ApplicationName,
+	} {
+		if val != "" {
+			params[key] = val
+		}
+	}
+	if db.Port != 0 {
+		params["port"] = strconv.Itoa(db.Port)
+	}
+
+	if params["host"] == "" {
+		return "", errors.Wrap(ErrInvalidConnectionString, "no database host configured")
+	}
+
+	return formatDSN(params), nil
+}
+
+// ParseURL - Turn a postgres:// URL or a libpq DSN into a DSN, sslmode is only set when given
+func ParseURL(connectionString string) (string, error) {
+	params, err := parseConnectionString(connectionString)
+	if err != nil {
+		return "", err
+	}
+
+	return formatDSN(params), nil
+}
+
+func parseConnectionString(connectionString string) (map[string]string, error) {
+	connectionString = strings.TrimSpace(connectionString)
+	switch {
+	case strings.HasPrefix(connectionString, "postgres://"), strings.HasPrefix(connectionString, "postgresql://"):
+		return parsePostgresURL(connectionString)
+	case strings.Contains(connectionString, "="):
+		return parseDSN(connectionString)
+	default:
+		return nil, errors.Wrap(ErrInvalidConnectionString, "expected a postgres:// URL or a key=value DSN")
+	}
+}
+
+// parsePostgresURL - postgres://[user[:password]@]host[:port][/dbname][?option=value...]
+// The host ends at the first '/' or '?', and the user info at the last '@' before it, so that '@' and ':'
+// in passwords that were not escaped still parse. A '/' or '?' in a password must be percent-encoded.
+func parsePostgresURL(connectionString string) (map[string]string, error) {
+	_, rest, _ := strings.Cut(connectionString, "://")
+	params := map[string]string{}
+
+	end := strings.IndexAny(rest, "/?")
+	if end < 0 {
+		end = len(rest)
+	}
+	hostport, rest := rest[:end], rest[end:]
+	if at := strings.LastIndex(hostport, "@"); at >= 0 {
+		user, password, hasPassword := strings.Cut(hostport[:at], ":")
+		params["user"] = unescape(user)
+		if hasPassword {
+			params["password"] = unescape(password)
+		}
+		hostport = hostport[at+1:]
+	}
+
+	path, query, _ := strings.Cut(rest, "?")
+	if dbname := strings.TrimPrefix(path, "/"); dbname != "" {
+		params["dbname"] = unescape(dbname)
+	}
+
+	if hostport == "" {
+		return nil, errors.Wrap(ErrInvalidConnectionString, "no host in the URL")
+	}
+	host, port := hostport, ""
+	if strings.HasPrefix(hostport, "[") || strings.Count(hostport, ":") == 1 {
+		var err error
+		if host, port, err = net.SplitHostPort(hostport); err != nil {
+			// An IPv6 address in brackets without a port
+			host = strings.Trim(hostport, "[]")
+		}
+	}
+	params["host"] = host
+	if port != "" {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return nil, errors.Wrapf(ErrInvalidConnectionString, "invalid port '%s'", port)
+		}
+		params["port"] = port
+	}
+
+	options, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidConnectionString, "invalid options: %v", err)
+	}
+	for key, vals := range options {
+		params[key] = vals[len(vals)-1]
+	}
+
+	return params, nil
+}
+
+// parseDSN - key=value pairs separated by spaces, values may be single-quoted with \' and \\ escapes
+func parseDSN(dsn string) (map[string]string, error) {
+	params := map[string]string{}
+
+	for i := 0; i < len(dsn); {
+		// Skip the separators
+		if dsn[i] == ' ' || dsn[i] == '\t' || dsn[i] == '\n' {
+			i++
+			continue
+		}
+
+		eq := strings.IndexByte(dsn[i:], '=')
+		if eq < 0 {
+			return nil, errors.Wrapf(ErrInvalidConnectionString, "missing '=' after '%s'", dsn[i:])
+		}
+		key := strings.TrimSpace(dsn[i : i+eq])
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, errors.Wrapf(ErrInvalidConnectionString, "invalid key '%s'", key)
+		}
+		i += eq + 1
+		for i < len(dsn) && dsn[i] == ' ' {
+			i++
+		}
+
+		var val strings.Builder
+		if i < len(dsn) && dsn[i] == '\'' {
+			i++
+			closed := false
+			for i < len(dsn) {
+				c := dsn[i]
+				if c == '\\' && i+1 < len(dsn) {
+					val.WriteByte(dsn[i+1])
+					i += 2
+					continue
+				}
+				i++
+				if c == '\'' {
+					closed = true
+					break
+				}
+				val.WriteByte(c)
+			}
+			if !closed {
+				return nil, errors.Wrapf(ErrInvalidConnectionString, "unterminated quoted value of '%s'", key)
+			}
+		} else {
+			for i < len(dsn) && dsn[i] != ' ' && dsn[i] != '\t' && dsn[i] != '\n' {
+				if dsn[i] == '\\' && i+1 < len(dsn) {
+					i++
+				}
+				val.WriteByte(dsn[i])
+				i++
+			}
+		}
+		params[key] = val.String()
+	}
+
+	if len(params) == 0 {
+		return nil, errors.Wrap(ErrInvalidConnectionString, "empty DSN")
+	}
+
+	return params, nil
+}
+
+// formatDSN - Quote every value, so that spaces and quotes in passwords survive
+func formatDSN(params map[string]string) string {
+	keys := []string{}
+	for _, key := range dsnKeys {
+		if _, ok := params[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	others := []string{}
+	for key := range params {
+		if !isDSNKey(key) {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	keys = append(keys, others...)
+
+	pairs := make([]string, 0, len(keys))
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, key := range keys {
+		pairs = append(pairs, key+"='"+escaper.Replace(params[key])+"'")
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+func isDSNKey(key string) bool {
+	for _, k := range dsnKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// unescape - Percent-decode a URL part, keeping it as is when it was not escaped
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// ListExclusions - Read the exclusion list
+func (db *Db) ListExclusions(ctx context.Context) ([]models.Exclusion, error) {
+	exclusions := []models.Exclusion{}
+	if err := db.retry(ctx, "list_exclusions", func() error {
+		return db.Client.WithContext(ctx).Order("address asc").Find(&exclusions).Error
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed reading from the exclusions table")
+	}
+
+	return exclusions, nil
+}
+
+// AddExclusion - Add an address to the exclusion list, or update its reason, and audit the change
+func (db *Db) AddExclusion(ctx context.Context, exclusion *models.Exclusion, actor string) error {
+	defer observeWrite("add_exclusion")()
+
+	now := time.Now()
+	exclusion.CreatedBy = actor
+	exclusion.CreatedAt = now
+
+	return db.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reason", "created_by", "created_at"}),
+		}).Create(exclusion).Error; err != nil {
+			return errors.Wrapf(err, "failed excluding '%s'", exclusion.Address)
+		}
+
+		return tx.Create(&models.ExclusionAudit{
+			Address: exclusion.Address,
+			Action:  models.ExclusionAdded,
+			Reason:  exclusion.Reason,
+			Actor:   actor,
+			At:      now,
+		}).Error
+	})
+}
+
+// RemoveExclusion - Remove an address from the exclusion list and audit the change
+func (db *Db) RemoveExclusion(ctx context.Context, address, reason, actor string) error {
+	defer observeWrite("remove_exclusion")()
+
+	return db.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("address = ?", address).Delete(&models.Exclusion{})
+		if res.Error != nil {
+			return errors.Wrapf(res.Error, "failed removing the exclusion of '%s'", address)
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		return tx.Create(&models.ExclusionAudit{
+			Address: address,
+			Action:  models.ExclusionRemoved,
+			Reason:  reason,
+			Actor:   actor,
+			At:      time.Now(),
+		}).Error
+	})
+}
+
+// ListExclusionAudits - Read the latest changes of the exclusion list, of one address when not empty
+func (db *Db) ListExclusionAudits(ctx context.Context, address string, limit int) ([]models.ExclusionAudit, error) {
+	tx := db.Client.WithContext(ctx)
+	if address != "" {
+		tx = tx.Where("address = ?", address)
+	}
+	if limit <= 0 || limit > maxPageSize {
+		limit = defaultPageSize
+	}
+
+	// A new session so that a retry does not add the clauses again
+	tx = tx.Session(&gorm.Session{})
+	audits := []models.ExclusionAudit{}
+	if err := db.retry(ctx, "list_exclusion_audits", func() error {
+		return tx.Order("id desc").Limit(limit).Find(&audits).Error
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed reading from the exclusion_audits table")
+	}
+
+	return audits, nil
+}
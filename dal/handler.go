@@ -3,7 +3,11 @@ package dal
 import (
 	"context"
 	"fmt"
+	stdlog "log"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"gorm.io/gorm/clause"
 
@@ -20,25 +24,90 @@ import (
 const (
 	tblaccount  = "accounts"
 	tblproposal = "proposals"
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
+
+	// freezeBatch - Votes frozen per statement
+	freezeBatch = 500
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrVoteFrozen is returned when changing a vote of a concluded proposal
+	ErrVoteFrozen = errors.New("vote is frozen")
+	// ErrStaleVote is returned when a vote is not newer than the one already recorded
+	ErrStaleVote = errors.New("vote is not newer than the recorded one")
 )
 
 type Db struct {
 	Client *gorm.DB
 	Cfg    *models.Config
 	Log    logger.Logger
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // NewDb ...
 func NewDb(cfg *models.Config, log logger.Logger) (*Db, error) {
-	log.Info(cfg.ConnectionString)
-	db, err := gorm.Open(postgres.Open(ParseURL(cfg.ConnectionString)), &gorm.Config{
-		Logger: glogger.Default.LogMode(glogger.Info),
+	dsn, err := DSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Connecting to %s", models.RedactConnectionString(dsn))
+
+	return openDb(postgres.Open(dsn), cfg, log)
+}
+
+// Open - The repository selected by database.driver
+func Open(cfg *models.Config, log logger.Logger) (Repo, error) {
+	var db *Db
+	var err error
+	switch cfg.Database.Driver {
+	case "", "postgres":
+		db, err = NewDb(cfg, log)
+	case "sqlite":
+		db, err = NewSqlite(cfg.Database.Name, cfg, log)
+	case "memory":
+		log.Info("Using the in-memory repository, nothing is persisted")
+		return NewMemory(), nil
+	default:
+		return nil, errors.Errorf("unknown database driver '%s'", cfg.Database.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// openDb - Open a GORM pool with the logging, pool and tracing settings of the configuration
+func openDb(dialector gorm.Dialector, cfg *models.Config, log logger.Logger) (*Db, error) {
+	level, err := logLevel(cfg.Database.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: glogger.New(stdlog.New(os.Stdout, "\r\n", stdlog.LstdFlags), glogger.Config{
+			SlowThreshold:             time.Duration(cfg.Database.SlowQueryMs) * time.Millisecond,
+			LogLevel:                  level,
+			IgnoreRecordNotFoundError: true,
+		}),
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed openning a DB connection")
 	}
-	// defer db.Close()
-
+	if err := configurePool(db, cfg.Database); err != nil {
+		return nil, err
+	}
+	if cfg.Tracing.Enabled {
+		if err := db.Use(newTracingPlugin()); err != nil {
+			return nil, errors.Wrap(err, "Failed registering the tracing plugin")
+		}
+	}
 	return &Db{
 		Client: db,
 		Cfg:    cfg,
@@ -46,15 +115,79 @@ func NewDb(cfg *models.Config, log logger.Logger) (*Db, error) {
 	}, nil
 }
 
-// Close ...
-func (db *Db) Close() {
-	db.Close()
+// configurePool - Apply the connection pool settings, zero keeps the driver default
+func configurePool(db *gorm.DB, cfg models.DatabaseConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Wrap(err, "Failed getting the database handle")
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetimeSeconds > 0 {
+		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+	}
+	if cfg.ConnMaxIdleTimeSeconds > 0 {
+		sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeSeconds) * time.Second)
+	}
+	return nil
+}
+
+// logLevel - The GORM log level of a configured name, warn logs the slow queries only
+func logLevel(name string) (glogger.LogLevel, error) {
+	switch name {
+	case "silent":
+		return glogger.Silent, nil
+	case "error":
+		return glogger.Error, nil
+	case "", "warn":
+		return glogger.Warn, nil
+	case "info":
+		return glogger.Info, nil
+	default:
+		return glogger.Silent, errors.Errorf("unknown database log level '%s'", name)
+	}
+}
+
+// Close - Close the connection pool, the first call only does it and the next ones return its error
+func (db *Db) Close() error {
+	db.closeOnce.Do(func() {
+		sqlDB, err := db.Client.DB()
+		if err != nil {
+			db.closeErr = errors.Wrap(err, "failed getting the database handle")
+			return
+		}
+		if err := sqlDB.Close(); err != nil {
+			db.closeErr = errors.Wrap(err, "failed closing the database")
+		}
+	})
+
+	return db.closeErr
+}
+
+// Ping - Check that the database is reachable
+func (db *Db) Ping(ctx context.Context) error {
+	sqlDB, err := db.Client.DB()
+	if err != nil {
+		return errors.Wrap(err, "failed getting the database handle")
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return errors.Wrap(err, "failed pinging the database")
+	}
+
+	return nil
 }
 
 // ListAccount - Read all existing accounts
-func (db *Db) ListAccount() ([]models.VotingSetup, error) {
+func (db *Db) ListAccount(ctx context.Context) ([]models.VotingSetup, error) {
 	accounts := []models.VotingSetup{}
-	if err := db.Client.Order("address asc").Find(&accounts).Error; err != nil {
+	if err := db.retry(ctx, "list_account", func() error {
+		return db.Client.WithContext(ctx).Order("address asc").Find(&accounts).Error
+	}); err != nil {
 		return nil, errors.Wrap(err, "failed reading from the accounts table")
 	}
 
@@ -63,83 +196,408 @@ func (db *Db) ListAccount() ([]models.VotingSetup, error) {
 
 // InsertVotingList -
 func (db *Db) UpsertVotingList(ctx context.Context, votings []models.VotingSetup) error {
-	trackingNumber := ctx.Value("tracking_number").(string)
+	defer observeWrite("upsert_voting_list")()
+
+	trackingNumber, _ := ctx.Value("tracking_number").(string)
 	db.Log.Infof("%s | Inserting '%d' account voting into the accounts table", trackingNumber, len(votings))
 
-	return db.Client.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "address"}},
-		DoUpdates: clause.AssignmentColumns([]string{"currency_seat_date", "votes"}),
-	}).CreateInBatches(votings, 1000).Error
+	// An upsert, running it again is harmless
+	return db.retry(ctx, "upsert_voting_list", func() error {
+		return db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"currency_seat_date", "votes", "effective_votes", "eligible", "eligibility_reason"}),
+		}).CreateInBatches(votings, 1000).Error
+	})
 }
 
 // Unseat -
 func (db *Db) Unseat(ctx context.Context, addresses []string) error {
+	defer observeWrite("unseat")()
+
 	trackingNumber, _ := ctx.Value("tracking_number").(string)
 	db.Log.Infof("%s | Try to update upto '%d' accounts that lost their seats, if existed", trackingNumber, len(addresses))
 
-	if res := db.Client.Table(tblaccount).Where("address IN ?", addresses).Updates(map[string]interface{}{"currency_seat_date": "0001-01-01", "votes": 0.0}); res.Error == nil {
-		db.Log.Infof("%s | Unseated '%d' accounts", trackingNumber, res.RowsAffected)
-		return nil
-	} else {
+	var unseated int64
+	if err := db.retry(ctx, "unseat", func() error {
+		res := db.Client.WithContext(ctx).Table(tblaccount).Where("address IN ?", addresses).Updates(map[string]interface{}{"currency_seat_date": "0001-01-01", "votes": 0.0, "effective_votes": 0.0})
+		unseated = res.RowsAffected
 		return res.Error
+	}); err != nil {
+		return err
 	}
+	db.Log.Infof("%s | Unseated '%d' accounts", trackingNumber, unseated)
+	return nil
 }
 
-// ListActiveProposal - Read all existing accounts
-func (db *Db) ListActiveProposal() ([]models.Proposal, error) {
+// ListActiveProposal - Read the approved proposals whose votes are not frozen yet, by closing date
+func (db *Db) ListActiveProposal(ctx context.Context) ([]models.Proposal, error) {
 	proposals := []models.Proposal{}
-	if err := db.Client.Where("concluded IS NULL AND is_approved = TRUE").Order("closing_date asc").Find(&proposals).Error; err != nil {
-		return nil, errors.Wrap(err, "failed reading from the accounts table")
+	if err := db.retry(ctx, "list_active_proposal", func() error {
+		return db.Client.WithContext(ctx).Where("is_approved = TRUE AND NOT COALESCE(concluded, FALSE)").Order("closing_date asc").Find(&proposals).Error
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed reading from the proposals table")
 	}
 
 	return proposals, nil
 }
 
-// UpdateConcludedVotes -
+// UpdateConcludedVotes - Freeze the voting power of every voter of a proposal, delegated power included.
+// A concluded proposal is left as it is, see ReconcludeProposal.
 func (db *Db) UpdateConcludedVotes(ctx context.Context, proposalId int64) error {
+	defer observeWrite("update_concluded_votes")()
+
 	trackingNumber, _ := ctx.Value("tracking_number").(string)
 	db.Log.Infof("%s | Update concluded votes for proposal '%d'", trackingNumber, proposalId)
 
-	if res := db.Client.Exec(`
-		update public.votes v
-		   set concluded_votes = COALESCE(a.votes,0)
-			from public.accounts a
-		 where v.user_address = a.address
-		   and v.concluded_votes is null and v.proposal_id = ?`, proposalId); res.Error == nil {
-		db.Log.Infof("%s | Updated '%d' concluded votes", trackingNumber, res.RowsAffected)
-		return nil
-	} else {
-		return res.Error
+	// Only the votes not frozen yet are updated, the whole transaction may run again
+	return db.retry(ctx, "update_concluded_votes", func() error {
+		return db.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var concluded int64
+			if err := tx.Model(&models.Proposal{}).Where("proposal_id = ? AND concluded = TRUE", proposalId).Count(&concluded).Error; err != nil {
+				return errors.Wrapf(err, "failed reading proposal '%d'", proposalId)
+			}
+			if concluded > 0 {
+				db.Log.Infof("%s | Proposal '%d' is already concluded", trackingNumber, proposalId)
+				return nil
+			}
+
+			updated, err := db.freezeVotes(tx, proposalId)
+			if err != nil {
+				return err
+			}
+
+			db.Log.Infof("%s | Updated '%d' concluded votes", trackingNumber, updated)
+			return nil
+		})
+	})
+}
+
+// ReconcludeProposal - Discard the frozen votes of a proposal and freeze them again from the current voting power
+func (db *Db) ReconcludeProposal(ctx context.Context, proposalId int64) error {
+	defer observeWrite("reconclude_proposal")()
+
+	trackingNumber, _ := ctx.Value("tracking_number").(string)
+	db.Log.Infof("%s | Reconclude proposal '%d'", trackingNumber, proposalId)
+
+	return db.retry(ctx, "reconclude_proposal", func() error {
+		return db.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Vote{}).Where("proposal_id = ?", proposalId).Update("concluded_votes", nil).Error; err != nil {
+				return errors.Wrapf(err, "failed discarding concluded votes of proposal '%d'", proposalId)
+			}
+			if err := tx.Model(&models.Proposal{}).Where("proposal_id = ?", proposalId).Update("concluded_total_power", nil).Error; err != nil {
+				return errors.Wrapf(err, "failed discarding the total voting power of proposal '%d'", proposalId)
+			}
+
+			updated, err := db.freezeVotes(tx, proposalId)
+			if err != nil {
+				return err
+			}
+
+			db.Log.Infof("%s | Updated '%d' concluded votes", trackingNumber, updated)
+			return nil
+		})
+	})
+}
+
+// freezeVotes - Record the voting power of the votes of a proposal that are not frozen yet, and the
+// total voting power unless already recorded. The proposal is marked concluded so that later runs skip it.
+func (db *Db) freezeVotes(tx *gorm.DB, proposalId int64) (int64, error) {
+	votes, weights, err := db.voteWeights(tx, proposalId)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := []models.Vote{}
+	for _, vote := range votes {
+		if vote.ConcludedVotes == nil {
+			pending = append(pending, vote)
+		}
+	}
+
+	// A statement per batch of votes rather than per vote
+	var updated int64
+	for start := 0; start < len(pending); start += freezeBatch {
+		end := start + freezeBatch
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		var stmt strings.Builder
+		args := make([]interface{}, 0, 2*(end-start)+1)
+		ids := make([]int64, 0, end-start)
+		stmt.WriteString("UPDATE votes SET concluded_votes = CASE id")
+		for _, vote := range pending[start:end] {
+			stmt.WriteString(" WHEN ? THEN CAST(? AS double precision)")
+			args = append(args, vote.ID, weights[vote.UserAddress])
+			ids = append(ids, vote.ID)
+		}
+		stmt.WriteString(" END WHERE id IN ? AND concluded_votes IS NULL")
+		args = append(args, ids)
+
+		res := tx.Exec(stmt.String(), args...)
+		if res.Error != nil {
+			return 0, errors.Wrapf(res.Error, "failed freezing votes of proposal '%d'", proposalId)
+		}
+		updated += res.RowsAffected
+	}
+
+	// The total is recorded once, so that the frozen turnout does not change with later runs
+	if err := tx.Exec(`
+		UPDATE proposals
+		SET concluded = TRUE,
+			concluded_total_power = COALESCE(concluded_total_power, (SELECT COALESCE(SUM(votes), 0) FROM accounts))
+		WHERE proposal_id = ?`, proposalId).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed concluding proposal '%d'", proposalId)
+	}
+
+	return updated, nil
+}
+
+// CastVote - Record a vote, or change the choice of an existing one with a newer vote until it is frozen
+func (db *Db) CastVote(ctx context.Context, vote *models.Vote) error {
+	defer observeWrite("cast_vote")()
+
+	if vote.CastAt.IsZero() {
+		vote.CastAt = time.Now()
+	}
+
+	res := db.Client.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "proposal_id"}, {Name: "user_address"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "votes.concluded_votes IS NULL AND votes.cast_at < excluded.cast_at"}}},
+		DoUpdates: clause.AssignmentColumns([]string{"choice", "cast_at"}),
+	}).Create(vote)
+	if res.Error != nil {
+		return errors.Wrapf(res.Error, "failed casting vote of '%s' on proposal '%d'", vote.UserAddress, vote.ProposalID)
+	}
+	if res.RowsAffected == 0 {
+		existing, err := db.GetVote(ctx, vote.ProposalID, vote.UserAddress)
+		if err != nil {
+			return err
+		}
+		if existing.ConcludedVotes != nil {
+			return ErrVoteFrozen
+		}
+		return ErrStaleVote
+	}
+
+	return nil
+}
+
+// GetVote - Read the vote of an account on a proposal
+func (db *Db) GetVote(ctx context.Context, proposalId int64, address string) (*models.Vote, error) {
+	var vote models.Vote
+	if err := db.retry(ctx, "get_vote", func() error {
+		return db.Client.WithContext(ctx).Where("proposal_id = ? AND user_address = ?", proposalId, address).Take(&vote).Error
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed reading vote of '%s' on proposal '%d'", address, proposalId)
+	}
+
+	return &vote, nil
+}
+
+// ListVotes - Read the votes cast on a proposal
+func (db *Db) ListVotes(ctx context.Context, proposalId int64) ([]models.Vote, error) {
+	votes := []models.Vote{}
+	if err := db.retry(ctx, "list_votes", func() error {
+		return db.Client.WithContext(ctx).Where("proposal_id = ?", proposalId).Order("cast_at asc").Order("id asc").Find(&votes).Error
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed reading votes of proposal '%d'", proposalId)
 	}
+
+	return votes, nil
 }
 
-// ParseURL - Prase the DB connection string
-func ParseURL(connectionString string) string {
-	s := strings.Split(connectionString, ":")
-	// schema := s[0]
+// CreateProposal - Record a proposal. Proposals are written by the DAO front end, this is for
+// local development and tests. A proposal that is not concluded is stored with a NULL concluded flag.
+func (db *Db) CreateProposal(ctx context.Context, proposal *models.Proposal) error {
+	var concluded *bool
+	if proposal.Concluded {
+		concluded = &proposal.Concluded
+	}
+	if err := db.Client.WithContext(ctx).Raw(
+		"INSERT INTO proposals (is_approved, closing_date, concluded) VALUES (?, ?, ?) RETURNING proposal_id",
+		proposal.IsApproved, proposal.ClosingDate, concluded,
+	).Scan(&proposal.ProposalID).Error; err != nil {
+		return errors.Wrap(err, "failed creating proposal")
+	}
 
-	s1 := strings.Split(s[1], "/")
-	username := s1[2]
+	return nil
+}
 
-	s2 := strings.Split(s[3], "/")
-	port := s2[0]
-	dbName := s2[1]
+// GetProposal - Read a single proposal by id
+func (db *Db) GetProposal(ctx context.Context, proposalId int64) (*models.Proposal, error) {
+	var proposal models.Proposal
+	if err := db.retry(ctx, "get_proposal", func() error {
+		return db.Client.WithContext(ctx).Where("proposal_id = ?", proposalId).Take(&proposal).Error
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed reading proposal '%d'", proposalId)
+	}
 
-	s3 := strings.Split(s[2], "@")
-	password := s3[0]
-	dbHost := s3[1]
+	return &proposal, nil
+}
 
-	// dns := url.URL{
-	// 	User:     url.UserPassword(username, password),
-	// 	Scheme:   schema,
-	// 	Host:     fmt.Sprintf("%s:%s", dbHost, port),
-	// 	Path:     dbName,
-	// 	RawQuery: (&url.Values{"sslmode": []string{"disable"}}).Encode(),
-	// }
+// LiveTally - Sum the current voting power of each voter by choice, delegated power included
+func (db *Db) LiveTally(ctx context.Context, proposalId int64) (*models.Tally, error) {
+	var votes []models.Vote
+	var weights map[string]float64
+	if err := db.retry(ctx, "live_tally", func() (err error) {
+		votes, weights, err = db.voteWeights(db.Client.WithContext(ctx), proposalId)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	tally := models.Tally{}
+	for _, vote := range votes {
+		if !tally.Add(vote.Choice, weights[vote.UserAddress]) {
+			db.Log.Warnf("Ignoring unknown choice '%s' on proposal '%d'", vote.Choice, proposalId)
+		}
+	}
+
+	return &tally, nil
+}
 
-	// fmt.Println(dns.String())
+// ConcludedTally - Sum the frozen voting power of each voter by choice
+func (db *Db) ConcludedTally(ctx context.Context, proposalId int64) (*models.Tally, error) {
+	rows := []struct {
+		Choice string
+		Power  float64
+	}{}
+	if err := db.retry(ctx, "concluded_tally", func() error {
+		return db.Client.WithContext(ctx).Model(&models.Vote{}).
+			Select("choice, COALESCE(SUM(concluded_votes),0) AS power").
+			Where("proposal_id = ?", proposalId).
+			Group("choice").
+			Scan(&rows).Error
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed tallying votes of proposal '%d'", proposalId)
+	}
 
-	// return dns.String()
+	tally := models.Tally{}
+	for _, row := range rows {
+		if !tally.Add(row.Choice, row.Power) {
+			db.Log.Warnf("Ignoring unknown choice '%s' on proposal '%d'", row.Choice, proposalId)
+		}
+	}
 
-	return fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=disable password=%s", dbHost, port, username, dbName, password)
+	return &tally, nil
+}
+
+// TotalVotingPower - Sum of the voting power of all accounts
+func (db *Db) TotalVotingPower(ctx context.Context) (float64, error) {
+	var total float64
+	if err := db.retry(ctx, "total_voting_power", func() error {
+		return db.Client.WithContext(ctx).Model(&models.VotingSetup{}).Select("COALESCE(SUM(votes), 0)").Scan(&total).Error
+	}); err != nil {
+		return 0, errors.Wrap(err, "failed summing the voting power")
+	}
+
+	return total, nil
+}
+
+// ListSeatedAccounts - Read a page of seated accounts and the total number of seated accounts
+func (db *Db) ListSeatedAccounts(ctx context.Context, query models.AccountQuery) ([]models.VotingSetup, int64, error) {
+	var total int64
+	seated := db.Client.WithContext(ctx).Model(&models.VotingSetup{}).Where("eligible AND currency_seat_date >= ?", models.SeatedSince).Session(&gorm.Session{})
+	if err := db.retry(ctx, "count_seated_accounts", func() error {
+		return seated.Count(&total).Error
+	}); err != nil {
+		return nil, 0, errors.Wrap(err, "failed counting seated accounts")
+	}
+
+	limit := query.PageLimit()
+
+	column := "votes"
+	if query.SortBy == models.SortBySeatDate {
+		column = "currency_seat_date"
+	}
+	order := clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: query.Desc}
+
+	accounts := []models.VotingSetup{}
+	if err := db.retry(ctx, "list_seated_accounts", func() error {
+		return seated.Order(order).Order("address asc").Limit(limit).Offset(query.Offset).Find(&accounts).Error
+	}); err != nil {
+		return nil, 0, errors.Wrap(err, "failed reading seated accounts")
+	}
+
+	return accounts, total, nil
+}
+
+// GetAccount - Read a single account by address
+func (db *Db) GetAccount(ctx context.Context, address string) (*models.VotingSetup, error) {
+	var account models.VotingSetup
+	if err := db.retry(ctx, "get_account", func() error {
+		return db.Client.WithContext(ctx).Where("address = ?", address).Take(&account).Error
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed reading account '%s'", address)
+	}
+
+	return &account, nil
+}
+
+// ListProposals - Read proposals by status, all of them when the status is empty
+func (db *Db) ListProposals(ctx context.Context, status string) ([]models.Proposal, error) {
+	tx := db.Client.WithContext(ctx)
+	switch status {
+	case "":
+	case models.ProposalStatusPending:
+		tx = tx.Where("is_approved IS NOT TRUE")
+	case models.ProposalStatusOpen:
+		tx = tx.Where("is_approved = TRUE AND concluded IS NOT TRUE AND closing_date > ?", time.Now())
+	case models.ProposalStatusConcluded:
+		tx = tx.Where("is_approved = TRUE AND (concluded = TRUE OR closing_date <= ?)", time.Now())
+	default:
+		return nil, fmt.Errorf("unknown proposal status '%s'", status)
+	}
+
+	// A new session so that a retry does not add the clauses again
+	tx = tx.Session(&gorm.Session{})
+	proposals := []models.Proposal{}
+	if err := db.retry(ctx, "list_proposals", func() error {
+		return tx.Order("closing_date asc").Find(&proposals).Error
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed reading from the proposals table")
+	}
+
+	return proposals, nil
+}
+
+// GetStats - Aggregate seat count, total votes and the seated top holders by voting power, none when top is zero
+func (db *Db) GetStats(ctx context.Context, top int) (*models.Stats, error) {
+	var totals struct {
+		SeatCount  int64
+		TotalVotes float64
+	}
+	if err := db.retry(ctx, "aggregate_accounts", func() error {
+		return db.Client.WithContext(ctx).Model(&models.VotingSetup{}).
+			Select("COUNT(*) FILTER (WHERE eligible AND currency_seat_date >= ?) AS seat_count, COALESCE(SUM(votes), 0) AS total_votes", models.SeatedSince).
+			Scan(&totals).Error
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed aggregating the accounts table")
+	}
+
+	holders := []models.VotingSetup{}
+	if top > 0 {
+		if err := db.retry(ctx, "list_top_holders", func() error {
+			return db.Client.WithContext(ctx).Where("eligible AND currency_seat_date >= ?", models.SeatedSince).
+				Order("votes desc").Order("address asc").Limit(top).Find(&holders).Error
+		}); err != nil {
+			return nil, errors.Wrap(err, "failed reading top holders")
+		}
+	}
+
+	return &models.Stats{
+		SeatCount:  totals.SeatCount,
+		TotalVotes: totals.TotalVotes,
+		TopHolders: holders,
+	}, nil
 }
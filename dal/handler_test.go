@@ -0,0 +1,67 @@
+package dal
+
+import (
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	glogger "gorm.io/gorm/logger"
+)
+
+// newUnconnectedDb - A Db whose pool never connected, enough to exercise Close
+func newUnconnectedDb(t *testing.T) *Db {
+	t.Helper()
+
+	client, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 user=test dbname=test"), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed opening the pool: %v", err)
+	}
+	return &Db{Client: client, Cfg: &models.Config{}, Log: &logger.NoopLogger{}}
+}
+
+func TestCloseClosesThePool(t *testing.T) {
+	db := newUnconnectedDb(t)
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close() = %v, want nil", err)
+	}
+
+	sqlDB, err := db.Client.DB()
+	if err != nil {
+		t.Fatalf("failed getting the pool: %v", err)
+	}
+	if err := sqlDB.Ping(); err == nil || err.Error() != "sql: database is closed" {
+		t.Errorf("Ping() after Close() = %v, want sql: database is closed", err)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	db := newUnconnectedDb(t)
+
+	for i := 0; i < 2; i++ {
+		if err := db.Close(); err != nil {
+			t.Fatalf("Close() #%d = %v, want nil", i+1, err)
+		}
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	for name, want := range map[string]glogger.LogLevel{
+		"":       glogger.Warn,
+		"warn":   glogger.Warn,
+		"silent": glogger.Silent,
+		"error":  glogger.Error,
+		"info":   glogger.Info,
+	} {
+		if got, err := logLevel(name); err != nil || got != want {
+			t.Errorf("logLevel(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := logLevel("debug"); err == nil {
+		t.Error("logLevel(debug) = nil, want an error")
+	}
+}
@@ -2,16 +2,48 @@ package dal
 
 import (
 	"context"
+	"time"
 
 	"github.com/ndau/dao-voting-setup/models"
 )
 
 //go:generate mockgen -destination=./mocks/mock_repo.go -package=mocks github.com/ndau/dao-voting-setup/dal Repo
 type Repo interface {
-	Close()
-	ListAccount() ([]models.VotingSetup, error)
+	Close() error
+	Ping(ctx context.Context) error
+	Migrate(ctx context.Context) error
+	ListAccount(ctx context.Context) ([]models.VotingSetup, error)
 	Unseat(ctx context.Context, addresses []string) error
 	UpsertVotingList(ctx context.Context, votings []models.VotingSetup) error
-	ListActiveProposal() ([]models.Proposal, error)
+	ListActiveProposal(ctx context.Context) ([]models.Proposal, error)
 	UpdateConcludedVotes(ctx context.Context, proposalId int64) error
+	ReconcludeProposal(ctx context.Context, proposalId int64) error
+	CastVote(ctx context.Context, vote *models.Vote) error
+	GetVote(ctx context.Context, proposalId int64, address string) (*models.Vote, error)
+	ListVotes(ctx context.Context, proposalId int64) ([]models.Vote, error)
+	ListSeatedAccounts(ctx context.Context, query models.AccountQuery) ([]models.VotingSetup, int64, error)
+	GetAccount(ctx context.Context, address string) (*models.VotingSetup, error)
+	ListProposals(ctx context.Context, status string) ([]models.Proposal, error)
+	GetStats(ctx context.Context, top int) (*models.Stats, error)
+	GetProposal(ctx context.Context, proposalId int64) (*models.Proposal, error)
+	LiveTally(ctx context.Context, proposalId int64) (*models.Tally, error)
+	ConcludedTally(ctx context.Context, proposalId int64) (*models.Tally, error)
+	TotalVotingPower(ctx context.Context) (float64, error)
+	SetDelegation(ctx context.Context, delegator, delegate string) error
+	RevokeDelegation(ctx context.Context, delegator string) error
+	ListDelegations(ctx context.Context) ([]models.Delegation, error)
+	ListExclusions(ctx context.Context) ([]models.Exclusion, error)
+	AddExclusion(ctx context.Context, exclusion *models.Exclusion, actor string) error
+	RemoveExclusion(ctx context.Context, address, reason, actor string) error
+	ListExclusionAudits(ctx context.Context, address string, limit int) ([]models.ExclusionAudit, error)
+	CreateSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+	FinishSnapshot(ctx context.Context, snapshot *models.Snapshot) error
+	SaveSnapshotAccounts(ctx context.Context, snapshotId int64, accounts []models.SnapshotAccount) error
+	GetSnapshot(ctx context.Context, snapshotId int64) (*models.Snapshot, error)
+	ListSnapshotAccounts(ctx context.Context, snapshotId int64) ([]models.SnapshotAccount, error)
+	SaveSnapshotDelegations(ctx context.Context, snapshotId int64, delegations []models.SnapshotDelegation) error
+	ListSnapshotDelegations(ctx context.Context, snapshotId int64) ([]models.SnapshotDelegation, error)
+	SaveSnapshotExclusions(ctx context.Context, snapshotId int64, exclusions []models.SnapshotExclusion) error
+	ListSnapshotExclusions(ctx context.Context, snapshotId int64) ([]models.SnapshotExclusion, error)
+	DeleteSnapshots(ctx context.Context, before time.Time) (int64, error)
 }
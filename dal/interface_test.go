@@ -0,0 +1,31 @@
+package dal_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/dal/mocks"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// The mock must follow the interface, run go generate ./dal/... after changing it
+var _ dal.Repo = (*mocks.MockRepo)(nil)
+
+func TestMockRepoRecordsVotes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+
+	vote := &models.Vote{ProposalID: 1, UserAddress: "a", Choice: models.ChoiceYes}
+	repo.EXPECT().CastVote(gomock.Any(), vote).Return(dal.ErrStaleVote)
+	repo.EXPECT().ListVotes(gomock.Any(), int64(1)).Return([]models.Vote{*vote}, nil)
+
+	var r dal.Repo = repo
+	if err := r.CastVote(context.Background(), vote); err != dal.ErrStaleVote {
+		t.Errorf("CastVote() = %v, want %v", err, dal.ErrStaleVote)
+	}
+	if votes, err := r.ListVotes(context.Background(), 1); err != nil || len(votes) != 1 {
+		t.Errorf("ListVotes() = %v, %v, want the recorded vote", votes, err)
+	}
+}
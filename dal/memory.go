@@ -0,0 +1,821 @@
+package dal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// errClosed is returned by the in-memory repository once closed
+var errClosed = errors.New("repository is closed")
+
+// Memory - A Repo holding everything in memory, for local development and tests.
+// It follows the semantics of the SQL implementation, nothing is persisted.
+type Memory struct {
+	mu sync.RWMutex
+
+	closed      bool
+	accounts    map[string]models.VotingSetup
+	proposals   map[int64]models.Proposal
+	votes       map[int64]models.Vote
+	delegations map[string]models.Delegation
+	exclusions  map[string]models.Exclusion
+	audits      []models.ExclusionAudit
+	snapshots   map[int64]models.Snapshot
+	// snapshotAccounts of each snapshot by address
+	snapshotAccounts map[int64]map[string]models.SnapshotAccount
+	// snapshotDelegations of each snapshot by delegator, snapshotExclusions by address
+	snapshotDelegations map[int64]map[string]models.SnapshotDelegation
+	snapshotExclusions  map[int64]map[string]models.SnapshotExclusion
+
+	// Last ids handed out, like the bigserial sequences
+	lastProposalID int64
+	lastVoteID     int64
+	lastAuditID    int64
+	lastSnapshotID int64
+}
+
+// NewMemory - An empty in-memory repository
+func NewMemory() *Memory {
+	return &Memory{
+		accounts:         map[string]models.VotingSetup{},
+		proposals:        map[int64]models.Proposal{},
+		votes:            map[int64]models.Vote{},
+		delegations:      map[string]models.Delegation{},
+		exclusions:       map[string]models.Exclusion{},
+		snapshots:        map[int64]models.Snapshot{},
+		snapshotAccounts: map[int64]map[string]models.SnapshotAccount{},
+
+		snapshotDelegations: map[int64]map[string]models.SnapshotDelegation{},
+		snapshotExclusions:  map[int64]map[string]models.SnapshotExclusion{},
+	}
+}
+
+// Close - Drop nothing, the content stays readable by the tests that hold the repository
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Ping - Fail once closed
+func (m *Memory) Ping(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return errClosed
+	}
+	return ctx.Err()
+}
+
+// Migrate - Nothing to migrate
+func (m *Memory) Migrate(ctx context.Context) error {
+	return nil
+}
+
+// ListAccount - Read all existing accounts
+func (m *Memory) ListAccount(ctx context.Context) ([]models.VotingSetup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accounts := make([]models.VotingSetup, 0, len(m.accounts))
+	for _, account := range m.accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Address < accounts[j].Address })
+
+	return accounts, nil
+}
+
+// UpsertVotingList - Insert the accounts or update the existing ones
+func (m *Memory) UpsertVotingList(ctx context.Context, votings []models.VotingSetup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, voting := range votings {
+		m.accounts[voting.Address] = voting
+	}
+	return nil
+}
+
+// Unseat - Zero the seat date and the voting power of the existing accounts
+func (m *Memory) Unseat(ctx context.Context, addresses []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, address := range addresses {
+		account, ok := m.accounts[address]
+		if !ok {
+			continue
+		}
+		account.CurrencySeatDate = time.Time{}
+		account.Votes = 0
+		account.EffectiveVotes = 0
+		m.accounts[address] = account
+	}
+	return nil
+}
+
+// CreateProposal - Record a proposal, see (*Db).CreateProposal
+func (m *Memory) CreateProposal(ctx context.Context, proposal *models.Proposal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if proposal.ProposalID == 0 {
+		proposal.ProposalID = m.lastProposalID + 1
+	} else if _, ok := m.proposals[proposal.ProposalID]; ok {
+		return fmt.Errorf("failed creating proposal: proposal '%d' already exists", proposal.ProposalID)
+	}
+	if proposal.ProposalID > m.lastProposalID {
+		m.lastProposalID = proposal.ProposalID
+	}
+	m.proposals[proposal.ProposalID] = *proposal
+	return nil
+}
+
+// ListActiveProposal - Read the approved proposals not concluded yet, by closing date
+func (m *Memory) ListActiveProposal(ctx context.Context) ([]models.Proposal, error) {
+	return m.listProposals(func(p models.Proposal) bool {
+		return p.IsApproved && !p.Concluded
+	}), nil
+}
+
+// ListProposals - Read proposals by status, all of them when the status is empty
+func (m *Memory) ListProposals(ctx context.Context, status string) ([]models.Proposal, error) {
+	switch status {
+	case "", models.ProposalStatusPending, models.ProposalStatusOpen, models.ProposalStatusConcluded:
+	default:
+		return nil, fmt.Errorf("unknown proposal status '%s'", status)
+	}
+
+	now := time.Now()
+	return m.listProposals(func(p models.Proposal) bool {
+		return status == "" || p.Status(now) == status
+	}), nil
+}
+
+// listProposals - The proposals matching a filter, by closing date
+func (m *Memory) listProposals(match func(models.Proposal) bool) []models.Proposal {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	proposals := []models.Proposal{}
+	for _, proposal := range m.proposals {
+		if match(proposal) {
+			proposals = append(proposals, proposal)
+		}
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		if !proposals[i].ClosingDate.Equal(proposals[j].ClosingDate) {
+			return proposals[i].ClosingDate.Before(proposals[j].ClosingDate)
+		}
+		return proposals[i].ProposalID < proposals[j].ProposalID
+	})
+	return proposals
+}
+
+// GetProposal - Read a single proposal by id
+func (m *Memory) GetProposal(ctx context.Context, proposalId int64) (*models.Proposal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	proposal, ok := m.proposals[proposalId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &proposal, nil
+}
+
+// UpdateConcludedVotes - Freeze the voting power of every voter of a proposal, delegated power included.
+// A concluded proposal is left as it is, see ReconcludeProposal.
+func (m *Memory) UpdateConcludedVotes(ctx context.Context, proposalId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.proposals[proposalId].Concluded {
+		return nil
+	}
+	m.freezeVotes(proposalId)
+	return nil
+}
+
+// ReconcludeProposal - Discard the frozen votes of a proposal and freeze them again from the current voting power
+func (m *Memory) ReconcludeProposal(ctx context.Context, proposalId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, vote := range m.votes {
+		if vote.ProposalID == proposalId {
+			vote.ConcludedVotes = nil
+			m.votes[id] = vote
+		}
+	}
+	if proposal, ok := m.proposals[proposalId]; ok {
+		proposal.ConcludedTotalPower = nil
+		m.proposals[proposalId] = proposal
+	}
+	m.freezeVotes(proposalId)
+	return nil
+}
+
+// freezeVotes - Record the voting power of the votes of a proposal that are not frozen yet, and the
+// total voting power unless already recorded. The proposal is marked concluded so that later runs skip it.
+func (m *Memory) freezeVotes(proposalId int64) {
+	votes, weights := m.voteWeights(proposalId)
+	for _, vote := range votes {
+		if vote.ConcludedVotes != nil {
+			continue
+		}
+		power := weights[vote.UserAddress]
+		vote.ConcludedVotes = &power
+		m.votes[vote.ID] = vote
+	}
+
+	proposal, ok := m.proposals[proposalId]
+	if !ok {
+		return
+	}
+	proposal.Concluded = true
+	if proposal.ConcludedTotalPower == nil {
+		total := 0.0
+		for _, account := range m.accounts {
+			total += account.Votes
+		}
+		proposal.ConcludedTotalPower = &total
+	}
+	m.proposals[proposalId] = proposal
+}
+
+// voteWeights - Voting power of each voter of a proposal, see models.Delegations.ResolveVoter
+func (m *Memory) voteWeights(proposalId int64) ([]models.Vote, map[string]float64) {
+	votes := m.proposalVotes(proposalId, func(a, b models.Vote) bool { return a.ID < b.ID })
+
+	list := make([]models.Delegation, 0, len(m.delegations))
+	for _, delegation := range m.delegations {
+		list = append(list, delegation)
+	}
+	delegations := models.NewDelegations(list)
+
+	voted := map[string]struct{}{}
+	for _, vote := range votes {
+		voted[vote.UserAddress] = struct{}{}
+	}
+
+	weights := map[string]float64{}
+	for _, account := range m.accounts {
+		if account.Votes <= 0 {
+			continue
+		}
+		if voter, ok := delegations.ResolveVoter(account.Address, voted, models.MaxDelegationDepth); ok {
+			weights[voter] += account.Votes
+		}
+	}
+
+	return votes, weights
+}
+
+// proposalVotes - Copies of the votes of a proposal in the given order
+func (m *Memory) proposalVotes(proposalId int64, less func(a, b models.Vote) bool) []models.Vote {
+	votes := []models.Vote{}
+	for _, vote := range m.votes {
+		if vote.ProposalID == proposalId {
+			votes = append(votes, copyVote(vote))
+		}
+	}
+	sort.Slice(votes, func(i, j int) bool { return less(votes[i], votes[j]) })
+	return votes
+}
+
+// copyVote - A vote that does not share its frozen power with the stored one
+func copyVote(vote models.Vote) models.Vote {
+	if vote.ConcludedVotes != nil {
+		power := *vote.ConcludedVotes
+		vote.ConcludedVotes = &power
+	}
+	return vote
+}
+
+// CastVote - Record a vote, or change the choice of an existing one with a newer vote until it is frozen
+func (m *Memory) CastVote(ctx context.Context, vote *models.Vote) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if vote.CastAt.IsZero() {
+		vote.CastAt = time.Now()
+	}
+
+	for id, existing := range m.votes {
+		if existing.ProposalID != vote.ProposalID || existing.UserAddress != vote.UserAddress {
+			continue
+		}
+		if existing.ConcludedVotes != nil {
+			return ErrVoteFrozen
+		}
+		if !existing.CastAt.Before(vote.CastAt) {
+			return ErrStaleVote
+		}
+		existing.Choice = vote.Choice
+		existing.CastAt = vote.CastAt
+		m.votes[id] = existing
+		vote.ID = id
+		return nil
+	}
+
+	m.lastVoteID++
+	vote.ID = m.lastVoteID
+	vote.ConcludedVotes = nil
+	m.votes[vote.ID] = *vote
+	return nil
+}
+
+// GetVote - Read the vote of an account on a proposal
+func (m *Memory) GetVote(ctx context.Context, proposalId int64, address string) (*models.Vote, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, vote := range m.votes {
+		if vote.ProposalID == proposalId && vote.UserAddress == address {
+			vote = copyVote(vote)
+			return &vote, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListVotes - Read the votes cast on a proposal
+func (m *Memory) ListVotes(ctx context.Context, proposalId int64) ([]models.Vote, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.proposalVotes(proposalId, func(a, b models.Vote) bool {
+		if !a.CastAt.Equal(b.CastAt) {
+			return a.CastAt.Before(b.CastAt)
+		}
+		return a.ID < b.ID
+	}), nil
+}
+
+// LiveTally - Sum the current voting power of each voter by choice, delegated power included
+func (m *Memory) LiveTally(ctx context.Context, proposalId int64) (*models.Tally, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	votes, weights := m.voteWeights(proposalId)
+	tally := models.Tally{}
+	for _, vote := range votes {
+		tally.Add(vote.Choice, weights[vote.UserAddress])
+	}
+	return &tally, nil
+}
+
+// ConcludedTally - Sum the frozen voting power of each voter by choice
+func (m *Memory) ConcludedTally(ctx context.Context, proposalId int64) (*models.Tally, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tally := models.Tally{}
+	for _, vote := range m.votes {
+		if vote.ProposalID != proposalId {
+			continue
+		}
+		power := 0.0
+		if vote.ConcludedVotes != nil {
+			power = *vote.ConcludedVotes
+		}
+		tally.Add(vote.Choice, power)
+	}
+	return &tally, nil
+}
+
+// TotalVotingPower - Sum of the voting power of all accounts
+func (m *Memory) TotalVotingPower(ctx context.Context) (float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	total := 0.0
+	for _, account := range m.accounts {
+		total += account.Votes
+	}
+	return total, nil
+}
+
+// seated - Whether an account counts as seated in the SQL queries
+func seated(account models.VotingSetup) bool {
+	return account.Eligible && !account.CurrencySeatDate.Before(models.SeatedSince)
+}
+
+// ListSeatedAccounts - Read a page of seated accounts and the total number of seated accounts
+func (m *Memory) ListSeatedAccounts(ctx context.Context, query models.AccountQuery) ([]models.VotingSetup, int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accounts := []models.VotingSetup{}
+	for _, account := range m.accounts {
+		if seated(account) {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		a, b := accounts[i], accounts[j]
+		if query.Desc {
+			a, b = b, a
+		}
+		switch {
+		case query.SortBy == models.SortBySeatDate && !a.CurrencySeatDate.Equal(b.CurrencySeatDate):
+			return a.CurrencySeatDate.Before(b.CurrencySeatDate)
+		case query.SortBy != models.SortBySeatDate && a.Votes != b.Votes:
+			return a.Votes < b.Votes
+		}
+		return accounts[i].Address < accounts[j].Address
+	})
+
+	return page(accounts, query.Offset, query.PageLimit()), int64(len(accounts)), nil
+}
+
+// page - The accounts from offset, at most limit of them
+func page(accounts []models.VotingSetup, offset, limit int) []models.VotingSetup {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(accounts) {
+		offset = len(accounts)
+	}
+	end := offset + limit
+	if end > len(accounts) {
+		end = len(accounts)
+	}
+	return accounts[offset:end]
+}
+
+// GetAccount - Read a single account by address
+func (m *Memory) GetAccount(ctx context.Context, address string) (*models.VotingSetup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	account, ok := m.accounts[address]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &account, nil
+}
+
+// GetStats - Aggregate seat count, total votes and the seated top holders by voting power, none when top is zero
+func (m *Memory) GetStats(ctx context.Context, top int) (*models.Stats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := models.Stats{TopHolders: []models.VotingSetup{}}
+	holders := make([]models.VotingSetup, 0, len(m.accounts))
+	for _, account := range m.accounts {
+		stats.TotalVotes += account.Votes
+		if seated(account) {
+			stats.SeatCount++
+			holders = append(holders, account)
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if holders[i].Votes != holders[j].Votes {
+			return holders[i].Votes > holders[j].Votes
+		}
+		return holders[i].Address < holders[j].Address
+	})
+	if top > 0 {
+		stats.TopHolders = page(holders, 0, top)
+	}
+
+	return &stats, nil
+}
+
+// SetDelegation - Delegate the voting power of an account, replacing its previous delegate
+func (m *Memory) SetDelegation(ctx context.Context, delegator, delegate string) error {
+	if delegator == delegate {
+		return errors.New("an account cannot delegate to itself")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delegations[delegator] = models.Delegation{
+		Delegator: delegator,
+		Delegate:  delegate,
+		CreatedAt: time.Now(),
+	}
+	return nil
+}
+
+// RevokeDelegation - Give the voting power back to the delegator
+func (m *Memory) RevokeDelegation(ctx context.Context, delegator string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.delegations[delegator]; !ok {
+		return ErrNotFound
+	}
+	delete(m.delegations, delegator)
+	return nil
+}
+
+// ListDelegations - Read all delegations
+func (m *Memory) ListDelegations(ctx context.Context) ([]models.Delegation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	delegations := make([]models.Delegation, 0, len(m.delegations))
+	for _, delegation := range m.delegations {
+		delegations = append(delegations, delegation)
+	}
+	sort.Slice(delegations, func(i, j int) bool { return delegations[i].Delegator < delegations[j].Delegator })
+	return delegations, nil
+}
+
+// ListExclusions - Read the exclusion list
+func (m *Memory) ListExclusions(ctx context.Context) ([]models.Exclusion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	exclusions := make([]models.Exclusion, 0, len(m.exclusions))
+	for _, exclusion := range m.exclusions {
+		exclusions = append(exclusions, exclusion)
+	}
+	sort.Slice(exclusions, func(i, j int) bool { return exclusions[i].Address < exclusions[j].Address })
+	return exclusions, nil
+}
+
+// AddExclusion - Add an address to the exclusion list, or update its reason, and audit the change
+func (m *Memory) AddExclusion(ctx context.Context, exclusion *models.Exclusion, actor string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	exclusion.CreatedBy = actor
+	exclusion.CreatedAt = now
+	m.exclusions[exclusion.Address] = *exclusion
+	m.audit(exclusion.Address, models.ExclusionAdded, exclusion.Reason, actor, now)
+	return nil
+}
+
+// RemoveExclusion - Remove an address from the exclusion list and audit the change
+func (m *Memory) RemoveExclusion(ctx context.Context, address, reason, actor string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.exclusions[address]; !ok {
+		return ErrNotFound
+	}
+	delete(m.exclusions, address)
+	m.audit(address, models.ExclusionRemoved, reason, actor, time.Now())
+	return nil
+}
+
+// audit - Record a change of the exclusion list
+func (m *Memory) audit(address, action, reason, actor string, at time.Time) {
+	m.lastAuditID++
+	m.audits = append(m.audits, models.ExclusionAudit{
+		ID:      m.lastAuditID,
+		Address: address,
+		Action:  action,
+		Reason:  reason,
+		Actor:   actor,
+		At:      at,
+	})
+}
+
+// ListExclusionAudits - Read the latest changes of the exclusion list, of one address when not empty
+func (m *Memory) ListExclusionAudits(ctx context.Context, address string, limit int) ([]models.ExclusionAudit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if limit <= 0 || limit > maxPageSize {
+		limit = defaultPageSize
+	}
+
+	audits := []models.ExclusionAudit{}
+	for i := len(m.audits) - 1; i >= 0 && len(audits) < limit; i-- {
+		if address == "" || m.audits[i].Address == address {
+			audits = append(audits, m.audits[i])
+		}
+	}
+	return audits, nil
+}
+
+// CreateSnapshot - Record the start of a run
+func (m *Memory) CreateSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if snapshot.ParentID != nil {
+		if _, ok := m.snapshots[*snapshot.ParentID]; !ok {
+			return fmt.Errorf("failed creating snapshot: parent snapshot '%d' does not exist", *snapshot.ParentID)
+		}
+	}
+	m.lastSnapshotID++
+	snapshot.ID = m.lastSnapshotID
+	m.snapshots[snapshot.ID] = copySnapshot(*snapshot)
+	return nil
+}
+
+// FinishSnapshot - Record the outcome of a run
+func (m *Memory) FinishSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	snapshot.FinishedAt = &now
+
+	stored, ok := m.snapshots[snapshot.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = snapshot.Status
+	stored.ChainTotalNdau = snapshot.ChainTotalNdau
+	stored.FinishedAt = &now
+	m.snapshots[snapshot.ID] = copySnapshot(stored)
+	return nil
+}
+
+// copySnapshot - A snapshot that does not share its pointers with another one
+func copySnapshot(snapshot models.Snapshot) models.Snapshot {
+	if snapshot.ParentID != nil {
+		parent := *snapshot.ParentID
+		snapshot.ParentID = &parent
+	}
+	if snapshot.FinishedAt != nil {
+		finished := *snapshot.FinishedAt
+		snapshot.FinishedAt = &finished
+	}
+	return snapshot
+}
+
+// SaveSnapshotAccounts - Store the accounts of a snapshot
+func (m *Memory) SaveSnapshotAccounts(ctx context.Context, snapshotId int64, accounts []models.SnapshotAccount) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(accounts) == 0 {
+		return nil
+	}
+	if _, ok := m.snapshots[snapshotId]; !ok {
+		return fmt.Errorf("failed saving accounts of snapshot '%d': snapshot does not exist", snapshotId)
+	}
+	stored := m.snapshotAccounts[snapshotId]
+	if stored == nil {
+		stored = map[string]models.SnapshotAccount{}
+	}
+	// Like a single insert, either every account is saved or none
+	for _, account := range accounts {
+		if _, ok := stored[account.Address]; ok {
+			return fmt.Errorf("failed saving accounts of snapshot '%d': account '%s' is already saved", snapshotId, account.Address)
+		}
+	}
+	for i := range accounts {
+		accounts[i].SnapshotID = snapshotId
+		stored[accounts[i].Address] = accounts[i]
+	}
+	m.snapshotAccounts[snapshotId] = stored
+	return nil
+}
+
+// GetSnapshot - Read a snapshot by id
+func (m *Memory) GetSnapshot(ctx context.Context, snapshotId int64) (*models.Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot, ok := m.snapshots[snapshotId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	snapshot = copySnapshot(snapshot)
+	return &snapshot, nil
+}
+
+// ListSnapshotAccounts - Read the accounts of a snapshot
+func (m *Memory) ListSnapshotAccounts(ctx context.Context, snapshotId int64) ([]models.SnapshotAccount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accounts := make([]models.SnapshotAccount, 0, len(m.snapshotAccounts[snapshotId]))
+	for _, account := range m.snapshotAccounts[snapshotId] {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Address < accounts[j].Address })
+	return accounts, nil
+}
+
+// SaveSnapshotDelegations - Store the delegations applied by a snapshot
+func (m *Memory) SaveSnapshotDelegations(ctx context.Context, snapshotId int64, delegations []models.SnapshotDelegation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(delegations) == 0 {
+		return nil
+	}
+	if _, ok := m.snapshots[snapshotId]; !ok {
+		return fmt.Errorf("failed saving delegations of snapshot '%d': snapshot does not exist", snapshotId)
+	}
+	stored := m.snapshotDelegations[snapshotId]
+	if stored == nil {
+		stored = map[string]models.SnapshotDelegation{}
+	}
+	for _, delegation := range delegations {
+		if _, ok := stored[delegation.Delegator]; ok {
+			return fmt.Errorf("failed saving delegations of snapshot '%d': delegator '%s' is already saved", snapshotId, delegation.Delegator)
+		}
+	}
+	for i := range delegations {
+		delegations[i].SnapshotID = snapshotId
+		stored[delegations[i].Delegator] = delegations[i]
+	}
+	m.snapshotDelegations[snapshotId] = stored
+	return nil
+}
+
+// ListSnapshotDelegations - Read the delegations applied by a snapshot
+func (m *Memory) ListSnapshotDelegations(ctx context.Context, snapshotId int64) ([]models.SnapshotDelegation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	delegations := make([]models.SnapshotDelegation, 0, len(m.snapshotDelegations[snapshotId]))
+	for _, delegation := range m.snapshotDelegations[snapshotId] {
+		delegations = append(delegations, delegation)
+	}
+	sort.Slice(delegations, func(i, j int) bool { return delegations[i].Delegator < delegations[j].Delegator })
+	return delegations, nil
+}
+
+// SaveSnapshotExclusions - Store the exclusions applied by a snapshot
+func (m *Memory) SaveSnapshotExclusions(ctx context.Context, snapshotId int64, exclusions []models.SnapshotExclusion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(exclusions) == 0 {
+		return nil
+	}
+	if _, ok := m.snapshots[snapshotId]; !ok {
+		return fmt.Errorf("failed saving exclusions of snapshot '%d': snapshot does not exist", snapshotId)
+	}
+	stored := m.snapshotExclusions[snapshotId]
+	if stored == nil {
+		stored = map[string]models.SnapshotExclusion{}
+	}
+	for _, exclusion := range exclusions {
+		if _, ok := stored[exclusion.Address]; ok {
+			return fmt.Errorf("failed saving exclusions of snapshot '%d': address '%s' is already saved", snapshotId, exclusion.Address)
+		}
+	}
+	for i := range exclusions {
+		exclusions[i].SnapshotID = snapshotId
+		stored[exclusions[i].Address] = exclusions[i]
+	}
+	m.snapshotExclusions[snapshotId] = stored
+	return nil
+}
+
+// ListSnapshotExclusions - Read the exclusions applied by a snapshot
+func (m *Memory) ListSnapshotExclusions(ctx context.Context, snapshotId int64) ([]models.SnapshotExclusion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	exclusions := make([]models.SnapshotExclusion, 0, len(m.snapshotExclusions[snapshotId]))
+	for _, exclusion := range m.snapshotExclusions[snapshotId] {
+		exclusions = append(exclusions, exclusion)
+	}
+	sort.Slice(exclusions, func(i, j int) bool { return exclusions[i].Address < exclusions[j].Address })
+	return exclusions, nil
+}
+
+// DeleteSnapshots - Delete the snapshots finished before a date, and those still running that started
+// before it, with their accounts, delegations and exclusions. The snapshots derived from a deleted one
+// lose their parent.
+func (m *Memory) DeleteSnapshots(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := map[int64]bool{}
+	for id, snapshot := range m.snapshots {
+		switch {
+		case snapshot.Status == models.SnapshotRunning && snapshot.StartedAt.Before(before):
+			expired[id] = true
+		case snapshot.Status != models.SnapshotRunning && snapshot.FinishedAt != nil && snapshot.FinishedAt.Before(before):
+			expired[id] = true
+		}
+	}
+	for id, snapshot := range m.snapshots {
+		if snapshot.ParentID != nil && expired[*snapshot.ParentID] {
+			snapshot.ParentID = nil
+			m.snapshots[id] = snapshot
+		}
+	}
+	for id := range expired {
+		delete(m.snapshots, id)
+		delete(m.snapshotAccounts, id)
+		delete(m.snapshotDelegations, id)
+		delete(m.snapshotExclusions, id)
+	}
+	return int64(len(expired)), nil
+}
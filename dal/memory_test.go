@@ -0,0 +1,16 @@
+package dal_test
+
+import (
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/dal/repotest"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repo {
+		repo := dal.NewMemory()
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	})
+}
@@ -0,0 +1,29 @@
+package dal
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var dbWriteDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "dao_voting",
+	Name:      "db_write_duration_seconds",
+	Help:      "Duration of the database writes by operation.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"operation"})
+
+// observeWrite - Time a database write, call the returned function when it ends
+func observeWrite(operation string) func() {
+	start := time.Now()
+	return func() {
+		dbWriteDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}
+}
+
+var dbRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "dao_voting",
+	Name:      "db_retries_total",
+	Help:      "Database operations run again after a transient error, by operation.",
+}, []string{"operation"})
@@ -0,0 +1,140 @@
+package dal
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const createSchemaVersion = `
+	CREATE TABLE IF NOT EXISTS public.schema_version (
+		version    integer PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`
+
+// migration - A versioned SQL script named <version>_<name>.sql
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// loadMigrations - Read the migration scripts of a directory ordered by version
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed listing migrations")
+	}
+
+	migrations := []migration{}
+	seen := map[int]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, suffix, found := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration '%s' is not named <version>_<name>.sql", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations '%s' and '%s' share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading migration '%s'", entry.Name())
+		}
+
+		migrations = append(migrations, migration{
+			Version: version,
+			Name:    suffix,
+			SQL:     string(script),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrate - Apply the pending schema migrations and record them in the schema_version table
+func (db *Db) Migrate(ctx context.Context) error {
+	if db.Client.Dialector.Name() == sqliteDialect {
+		return db.migrateSqlite(ctx)
+	}
+
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return err
+	}
+
+	client := db.Client.WithContext(ctx)
+	if err := client.Exec(createSchemaVersion).Error; err != nil {
+		return errors.Wrap(err, "failed creating the schema_version table")
+	}
+
+	// Applied migrations are skipped, a deadlock between pods starting together runs it again
+	return db.retry(ctx, "migrate", func() error {
+		return db.migrate(client, migrations)
+	})
+}
+
+// migrate - Apply the migrations missing from the schema_version table in a single transaction
+func (db *Db) migrate(client *gorm.DB, migrations []migration) error {
+	return client.Transaction(func(tx *gorm.DB) error {
+		// Serialize concurrent pods starting at the same time
+		if err := tx.Exec("LOCK TABLE public.schema_version IN EXCLUSIVE MODE").Error; err != nil {
+			return errors.Wrap(err, "failed locking the schema_version table")
+		}
+
+		applied := []models.SchemaVersion{}
+		if err := tx.Find(&applied).Error; err != nil {
+			return errors.Wrap(err, "failed reading the schema_version table")
+		}
+		done := map[int]struct{}{}
+		for _, version := range applied {
+			done[version.Version] = struct{}{}
+		}
+
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+
+			db.Log.Infof("Applying migration %04d_%s", m.Version, m.Name)
+			if err := tx.Exec(m.SQL).Error; err != nil {
+				return errors.Wrapf(err, "failed applying migration %04d_%s", m.Version, m.Name)
+			}
+			if err := tx.Create(&models.SchemaVersion{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error; err != nil {
+				return errors.Wrapf(err, "failed recording migration %04d_%s", m.Version, m.Name)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,79 @@
+package dal
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0010_later.sql":   {Data: []byte("SELECT 10")},
+		"migrations/0002_second.sql":  {Data: []byte("SELECT 2")},
+		"migrations/0001_initial.sql": {Data: []byte("SELECT 1")},
+		"migrations/README.md":        {Data: []byte("not a migration")},
+		"migrations/old/0003_x.sql":   {Data: []byte("in a sub directory")},
+	}
+
+	migrations, err := loadMigrations(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("loadMigrations() = %v", err)
+	}
+	want := []migration{
+		{Version: 1, Name: "initial", SQL: "SELECT 1"},
+		{Version: 2, Name: "second", SQL: "SELECT 2"},
+		{Version: 10, Name: "later", SQL: "SELECT 10"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("loadMigrations() = %+v, want %+v", migrations, want)
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsBadNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		err   string
+	}{
+		{"no version", []string{"initial.sql"}, "is not named <version>_<name>.sql"},
+		{"no name", []string{"0001.sql"}, "is not named <version>_<name>.sql"},
+		{"version zero", []string{"0000_initial.sql"}, "is not named <version>_<name>.sql"},
+		{"negative version", []string{"-1_initial.sql"}, "is not named <version>_<name>.sql"},
+		{"shared version", []string{"0001_initial.sql", "01_other.sql"}, "share version 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, file := range tt.files {
+				fsys["migrations/"+file] = &fstest.MapFile{Data: []byte("SELECT 1")}
+			}
+			if _, err := loadMigrations(fsys, "migrations"); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("loadMigrations() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("loadMigrations() = %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %04d_%s, want version %d: versions must follow each other", m.Version, m.Name, i+1)
+		}
+	}
+
+	// The unique index of the votes is only created once duplicates are ruled out
+	initial := migrations[0].SQL
+	check, index := strings.Index(initial, "HAVING count(*) > 1"), strings.Index(initial, "CREATE UNIQUE INDEX IF NOT EXISTS votes_proposal_id_user_address_idx")
+	if check < 0 || index < 0 || check > index {
+		t.Error("0001 must check for duplicate votes before creating their unique index")
+	}
+}
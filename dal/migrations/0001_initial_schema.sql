@@ -0,0 +1,46 @@
+-- Tables shared with the DAO voting front end. They may already exist on
+-- databases created before the service owned its schema.
+
+CREATE TABLE IF NOT EXISTS public.accounts (
+    address            text PRIMARY KEY,
+    currency_seat_date timestamptz NOT NULL DEFAULT '0001-01-01',
+    votes              double precision NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS accounts_currency_seat_date_idx ON public.accounts (currency_seat_date);
+CREATE INDEX IF NOT EXISTS accounts_votes_idx ON public.accounts (votes);
+
+CREATE TABLE IF NOT EXISTS public.proposals (
+    proposal_id  bigserial PRIMARY KEY,
+    is_approved  boolean,
+    closing_date timestamptz NOT NULL,
+    concluded    boolean
+);
+
+CREATE TABLE IF NOT EXISTS public.votes (
+    id              bigserial PRIMARY KEY,
+    proposal_id     bigint NOT NULL,
+    user_address    text NOT NULL,
+    choice          text NOT NULL,
+    cast_at         timestamptz NOT NULL DEFAULT now(),
+    concluded_votes double precision
+);
+
+ALTER TABLE public.votes ADD COLUMN IF NOT EXISTS choice text;
+ALTER TABLE public.votes ADD COLUMN IF NOT EXISTS cast_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE public.votes ADD COLUMN IF NOT EXISTS concluded_votes double precision;
+
+-- Older deployments may hold several votes of an account on a proposal, which the unique index
+-- rejects. They are not removed here since picking the vote to keep is up to the operator.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM public.votes GROUP BY proposal_id, user_address HAVING count(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'public.votes holds several votes of an account on a proposal, keep a single one per (proposal_id, user_address) and migrate again'
+            USING HINT = 'SELECT proposal_id, user_address, count(*) FROM public.votes GROUP BY 1, 2 HAVING count(*) > 1';
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS votes_proposal_id_user_address_idx ON public.votes (proposal_id, user_address);
@@ -0,0 +1,13 @@
+-- Liquid democracy: an account hands its voting power to another one
+
+CREATE TABLE IF NOT EXISTS public.delegations (
+    delegator  text PRIMARY KEY,
+    delegate   text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CHECK (delegator <> delegate)
+);
+
+CREATE INDEX IF NOT EXISTS delegations_delegate_idx ON public.delegations (delegate);
+
+-- Voting power after resolving delegations, next to the raw power in votes
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS effective_votes double precision NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Why each account takes part in the vote or not
+
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS eligible boolean NOT NULL DEFAULT true;
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS eligibility_reason text NOT NULL DEFAULT 'regular';
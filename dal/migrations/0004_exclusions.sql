@@ -0,0 +1,19 @@
+-- Addresses kept out of the vote (exchange hot wallets, treasury...) and the history of the list
+
+CREATE TABLE IF NOT EXISTS public.exclusions (
+    address    text PRIMARY KEY,
+    reason     text NOT NULL,
+    created_by text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.exclusion_audits (
+    id      bigserial PRIMARY KEY,
+    address text NOT NULL,
+    action  text NOT NULL,
+    reason  text NOT NULL,
+    actor   text NOT NULL,
+    at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS exclusion_audits_address_idx ON public.exclusion_audits (address);
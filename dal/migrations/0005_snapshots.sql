@@ -0,0 +1,25 @@
+-- Chain state and allocation of each run, so that the allocation can be recomputed without the chain
+
+CREATE TABLE IF NOT EXISTS public.snapshots (
+    id               bigserial PRIMARY KEY,
+    tracking_number  text NOT NULL,
+    parent_id        bigint REFERENCES public.snapshots (id),
+    policy           text NOT NULL,
+    status           text NOT NULL,
+    network          text NOT NULL DEFAULT '',
+    chain_total_ndau bigint NOT NULL DEFAULT 0,
+    started_at       timestamptz NOT NULL,
+    finished_at      timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS public.snapshot_accounts (
+    snapshot_id        bigint NOT NULL REFERENCES public.snapshots (id) ON DELETE CASCADE,
+    address            text NOT NULL,
+    balance            bigint NOT NULL,
+    currency_seat_date timestamptz NOT NULL,
+    class              text NOT NULL,
+    eligible           boolean NOT NULL,
+    votes              double precision NOT NULL DEFAULT 0,
+    effective_votes    double precision NOT NULL DEFAULT 0,
+    PRIMARY KEY (snapshot_id, address)
+);
@@ -0,0 +1,3 @@
+-- Total voting power when the votes of a proposal were frozen, the base of its frozen turnout
+
+ALTER TABLE public.proposals ADD COLUMN IF NOT EXISTS concluded_total_power double precision;
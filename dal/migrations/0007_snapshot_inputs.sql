@@ -0,0 +1,17 @@
+-- Delegations and exclusions used by each run, so that a recomputed snapshot does not depend on the current ones
+
+CREATE TABLE IF NOT EXISTS public.snapshot_delegations (
+    snapshot_id bigint NOT NULL REFERENCES public.snapshots (id) ON DELETE CASCADE,
+    delegator   text NOT NULL,
+    delegate    text NOT NULL,
+    PRIMARY KEY (snapshot_id, delegator)
+);
+
+CREATE TABLE IF NOT EXISTS public.snapshot_exclusions (
+    snapshot_id bigint NOT NULL REFERENCES public.snapshots (id) ON DELETE CASCADE,
+    address     text NOT NULL,
+    reason      text NOT NULL,
+    PRIMARY KEY (snapshot_id, address)
+);
+
+CREATE INDEX IF NOT EXISTS snapshots_finished_at_idx ON public.snapshots (finished_at);
@@ -0,0 +1,576 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ndau/dao-voting-setup/dal (interfaces: Repo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/ndau/dao-voting-setup/models"
+)
+
+// MockRepo is a mock of Repo interface.
+type MockRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepoMockRecorder
+}
+
+// MockRepoMockRecorder is the mock recorder for MockRepo.
+type MockRepoMockRecorder struct {
+	mock *MockRepo
+}
+
+// NewMockRepo creates a new mock instance.
+func NewMockRepo(ctrl *gomock.Controller) *MockRepo {
+	mock := &MockRepo{ctrl: ctrl}
+	mock.recorder = &MockRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepo) EXPECT() *MockRepoMockRecorder {
+	return m.recorder
+}
+
+// AddExclusion mocks base method.
+func (m *MockRepo) AddExclusion(arg0 context.Context, arg1 *models.Exclusion, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddExclusion", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddExclusion indicates an expected call of AddExclusion.
+func (mr *MockRepoMockRecorder) AddExclusion(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExclusion", reflect.TypeOf((*MockRepo)(nil).AddExclusion), arg0, arg1, arg2)
+}
+
+// CastVote mocks base method.
+func (m *MockRepo) CastVote(arg0 context.Context, arg1 *models.Vote) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CastVote", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CastVote indicates an expected call of CastVote.
+func (mr *MockRepoMockRecorder) CastVote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CastVote", reflect.TypeOf((*MockRepo)(nil).CastVote), arg0, arg1)
+}
+
+// Close mocks base method.
+func (m *MockRepo) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockRepoMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRepo)(nil).Close))
+}
+
+// ConcludedTally mocks base method.
+func (m *MockRepo) ConcludedTally(arg0 context.Context, arg1 int64) (*models.Tally, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcludedTally", arg0, arg1)
+	ret0, _ := ret[0].(*models.Tally)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConcludedTally indicates an expected call of ConcludedTally.
+func (mr *MockRepoMockRecorder) ConcludedTally(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcludedTally", reflect.TypeOf((*MockRepo)(nil).ConcludedTally), arg0, arg1)
+}
+
+// CreateSnapshot mocks base method.
+func (m *MockRepo) CreateSnapshot(arg0 context.Context, arg1 *models.Snapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSnapshot", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSnapshot indicates an expected call of CreateSnapshot.
+func (mr *MockRepoMockRecorder) CreateSnapshot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSnapshot", reflect.TypeOf((*MockRepo)(nil).CreateSnapshot), arg0, arg1)
+}
+
+// DeleteSnapshots mocks base method.
+func (m *MockRepo) DeleteSnapshots(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSnapshots", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSnapshots indicates an expected call of DeleteSnapshots.
+func (mr *MockRepoMockRecorder) DeleteSnapshots(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshots", reflect.TypeOf((*MockRepo)(nil).DeleteSnapshots), arg0, arg1)
+}
+
+// FinishSnapshot mocks base method.
+func (m *MockRepo) FinishSnapshot(arg0 context.Context, arg1 *models.Snapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishSnapshot", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishSnapshot indicates an expected call of FinishSnapshot.
+func (mr *MockRepoMockRecorder) FinishSnapshot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishSnapshot", reflect.TypeOf((*MockRepo)(nil).FinishSnapshot), arg0, arg1)
+}
+
+// GetAccount mocks base method.
+func (m *MockRepo) GetAccount(arg0 context.Context, arg1 string) (*models.VotingSetup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
+	ret0, _ := ret[0].(*models.VotingSetup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockRepoMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockRepo)(nil).GetAccount), arg0, arg1)
+}
+
+// GetProposal mocks base method.
+func (m *MockRepo) GetProposal(arg0 context.Context, arg1 int64) (*models.Proposal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProposal", arg0, arg1)
+	ret0, _ := ret[0].(*models.Proposal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProposal indicates an expected call of GetProposal.
+func (mr *MockRepoMockRecorder) GetProposal(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProposal", reflect.TypeOf((*MockRepo)(nil).GetProposal), arg0, arg1)
+}
+
+// GetSnapshot mocks base method.
+func (m *MockRepo) GetSnapshot(arg0 context.Context, arg1 int64) (*models.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshot", arg0, arg1)
+	ret0, _ := ret[0].(*models.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshot indicates an expected call of GetSnapshot.
+func (mr *MockRepoMockRecorder) GetSnapshot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshot", reflect.TypeOf((*MockRepo)(nil).GetSnapshot), arg0, arg1)
+}
+
+// GetStats mocks base method.
+func (m *MockRepo) GetStats(arg0 context.Context, arg1 int) (*models.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", arg0, arg1)
+	ret0, _ := ret[0].(*models.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockRepoMockRecorder) GetStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockRepo)(nil).GetStats), arg0, arg1)
+}
+
+// GetVote mocks base method.
+func (m *MockRepo) GetVote(arg0 context.Context, arg1 int64, arg2 string) (*models.Vote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVote", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Vote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVote indicates an expected call of GetVote.
+func (mr *MockRepoMockRecorder) GetVote(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVote", reflect.TypeOf((*MockRepo)(nil).GetVote), arg0, arg1, arg2)
+}
+
+// ListAccount mocks base method.
+func (m *MockRepo) ListAccount(arg0 context.Context) ([]models.VotingSetup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccount", arg0)
+	ret0, _ := ret[0].([]models.VotingSetup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccount indicates an expected call of ListAccount.
+func (mr *MockRepoMockRecorder) ListAccount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccount", reflect.TypeOf((*MockRepo)(nil).ListAccount), arg0)
+}
+
+// ListActiveProposal mocks base method.
+func (m *MockRepo) ListActiveProposal(arg0 context.Context) ([]models.Proposal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveProposal", arg0)
+	ret0, _ := ret[0].([]models.Proposal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveProposal indicates an expected call of ListActiveProposal.
+func (mr *MockRepoMockRecorder) ListActiveProposal(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveProposal", reflect.TypeOf((*MockRepo)(nil).ListActiveProposal), arg0)
+}
+
+// ListDelegations mocks base method.
+func (m *MockRepo) ListDelegations(arg0 context.Context) ([]models.Delegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDelegations", arg0)
+	ret0, _ := ret[0].([]models.Delegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDelegations indicates an expected call of ListDelegations.
+func (mr *MockRepoMockRecorder) ListDelegations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDelegations", reflect.TypeOf((*MockRepo)(nil).ListDelegations), arg0)
+}
+
+// ListExclusionAudits mocks base method.
+func (m *MockRepo) ListExclusionAudits(arg0 context.Context, arg1 string, arg2 int) ([]models.ExclusionAudit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExclusionAudits", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.ExclusionAudit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExclusionAudits indicates an expected call of ListExclusionAudits.
+func (mr *MockRepoMockRecorder) ListExclusionAudits(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExclusionAudits", reflect.TypeOf((*MockRepo)(nil).ListExclusionAudits), arg0, arg1, arg2)
+}
+
+// ListExclusions mocks base method.
+func (m *MockRepo) ListExclusions(arg0 context.Context) ([]models.Exclusion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExclusions", arg0)
+	ret0, _ := ret[0].([]models.Exclusion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExclusions indicates an expected call of ListExclusions.
+func (mr *MockRepoMockRecorder) ListExclusions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExclusions", reflect.TypeOf((*MockRepo)(nil).ListExclusions), arg0)
+}
+
+// ListProposals mocks base method.
+func (m *MockRepo) ListProposals(arg0 context.Context, arg1 string) ([]models.Proposal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProposals", arg0, arg1)
+	ret0, _ := ret[0].([]models.Proposal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProposals indicates an expected call of ListProposals.
+func (mr *MockRepoMockRecorder) ListProposals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProposals", reflect.TypeOf((*MockRepo)(nil).ListProposals), arg0, arg1)
+}
+
+// ListSeatedAccounts mocks base method.
+func (m *MockRepo) ListSeatedAccounts(arg0 context.Context, arg1 models.AccountQuery) ([]models.VotingSetup, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSeatedAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]models.VotingSetup)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListSeatedAccounts indicates an expected call of ListSeatedAccounts.
+func (mr *MockRepoMockRecorder) ListSeatedAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSeatedAccounts", reflect.TypeOf((*MockRepo)(nil).ListSeatedAccounts), arg0, arg1)
+}
+
+// ListSnapshotAccounts mocks base method.
+func (m *MockRepo) ListSnapshotAccounts(arg0 context.Context, arg1 int64) ([]models.SnapshotAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshotAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]models.SnapshotAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshotAccounts indicates an expected call of ListSnapshotAccounts.
+func (mr *MockRepoMockRecorder) ListSnapshotAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshotAccounts", reflect.TypeOf((*MockRepo)(nil).ListSnapshotAccounts), arg0, arg1)
+}
+
+// ListSnapshotDelegations mocks base method.
+func (m *MockRepo) ListSnapshotDelegations(arg0 context.Context, arg1 int64) ([]models.SnapshotDelegation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshotDelegations", arg0, arg1)
+	ret0, _ := ret[0].([]models.SnapshotDelegation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshotDelegations indicates an expected call of ListSnapshotDelegations.
+func (mr *MockRepoMockRecorder) ListSnapshotDelegations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshotDelegations", reflect.TypeOf((*MockRepo)(nil).ListSnapshotDelegations), arg0, arg1)
+}
+
+// ListSnapshotExclusions mocks base method.
+func (m *MockRepo) ListSnapshotExclusions(arg0 context.Context, arg1 int64) ([]models.SnapshotExclusion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshotExclusions", arg0, arg1)
+	ret0, _ := ret[0].([]models.SnapshotExclusion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshotExclusions indicates an expected call of ListSnapshotExclusions.
+func (mr *MockRepoMockRecorder) ListSnapshotExclusions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshotExclusions", reflect.TypeOf((*MockRepo)(nil).ListSnapshotExclusions), arg0, arg1)
+}
+
+// ListVotes mocks base method.
+func (m *MockRepo) ListVotes(arg0 context.Context, arg1 int64) ([]models.Vote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVotes", arg0, arg1)
+	ret0, _ := ret[0].([]models.Vote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVotes indicates an expected call of ListVotes.
+func (mr *MockRepoMockRecorder) ListVotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVotes", reflect.TypeOf((*MockRepo)(nil).ListVotes), arg0, arg1)
+}
+
+// LiveTally mocks base method.
+func (m *MockRepo) LiveTally(arg0 context.Context, arg1 int64) (*models.Tally, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LiveTally", arg0, arg1)
+	ret0, _ := ret[0].(*models.Tally)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LiveTally indicates an expected call of LiveTally.
+func (mr *MockRepoMockRecorder) LiveTally(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LiveTally", reflect.TypeOf((*MockRepo)(nil).LiveTally), arg0, arg1)
+}
+
+// Migrate mocks base method.
+func (m *MockRepo) Migrate(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Migrate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Migrate indicates an expected call of Migrate.
+func (mr *MockRepoMockRecorder) Migrate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Migrate", reflect.TypeOf((*MockRepo)(nil).Migrate), arg0)
+}
+
+// Ping mocks base method.
+func (m *MockRepo) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockRepoMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRepo)(nil).Ping), arg0)
+}
+
+// ReconcludeProposal mocks base method.
+func (m *MockRepo) ReconcludeProposal(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcludeProposal", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcludeProposal indicates an expected call of ReconcludeProposal.
+func (mr *MockRepoMockRecorder) ReconcludeProposal(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcludeProposal", reflect.TypeOf((*MockRepo)(nil).ReconcludeProposal), arg0, arg1)
+}
+
+// RemoveExclusion mocks base method.
+func (m *MockRepo) RemoveExclusion(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExclusion", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveExclusion indicates an expected call of RemoveExclusion.
+func (mr *MockRepoMockRecorder) RemoveExclusion(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExclusion", reflect.TypeOf((*MockRepo)(nil).RemoveExclusion), arg0, arg1, arg2, arg3)
+}
+
+// RevokeDelegation mocks base method.
+func (m *MockRepo) RevokeDelegation(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeDelegation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeDelegation indicates an expected call of RevokeDelegation.
+func (mr *MockRepoMockRecorder) RevokeDelegation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeDelegation", reflect.TypeOf((*MockRepo)(nil).RevokeDelegation), arg0, arg1)
+}
+
+// SaveSnapshotAccounts mocks base method.
+func (m *MockRepo) SaveSnapshotAccounts(arg0 context.Context, arg1 int64, arg2 []models.SnapshotAccount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSnapshotAccounts", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSnapshotAccounts indicates an expected call of SaveSnapshotAccounts.
+func (mr *MockRepoMockRecorder) SaveSnapshotAccounts(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSnapshotAccounts", reflect.TypeOf((*MockRepo)(nil).SaveSnapshotAccounts), arg0, arg1, arg2)
+}
+
+// SaveSnapshotDelegations mocks base method.
+func (m *MockRepo) SaveSnapshotDelegations(arg0 context.Context, arg1 int64, arg2 []models.SnapshotDelegation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSnapshotDelegations", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSnapshotDelegations indicates an expected call of SaveSnapshotDelegations.
+func (mr *MockRepoMockRecorder) SaveSnapshotDelegations(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSnapshotDelegations", reflect.TypeOf((*MockRepo)(nil).SaveSnapshotDelegations), arg0, arg1, arg2)
+}
+
+// SaveSnapshotExclusions mocks base method.
+func (m *MockRepo) SaveSnapshotExclusions(arg0 context.Context, arg1 int64, arg2 []models.SnapshotExclusion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSnapshotExclusions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSnapshotExclusions indicates an expected call of SaveSnapshotExclusions.
+func (mr *MockRepoMockRecorder) SaveSnapshotExclusions(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSnapshotExclusions", reflect.TypeOf((*MockRepo)(nil).SaveSnapshotExclusions), arg0, arg1, arg2)
+}
+
+// SetDelegation mocks base method.
+func (m *MockRepo) SetDelegation(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDelegation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDelegation indicates an expected call of SetDelegation.
+func (mr *MockRepoMockRecorder) SetDelegation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDelegation", reflect.TypeOf((*MockRepo)(nil).SetDelegation), arg0, arg1, arg2)
+}
+
+// TotalVotingPower mocks base method.
+func (m *MockRepo) TotalVotingPower(arg0 context.Context) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TotalVotingPower", arg0)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TotalVotingPower indicates an expected call of TotalVotingPower.
+func (mr *MockRepoMockRecorder) TotalVotingPower(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalVotingPower", reflect.TypeOf((*MockRepo)(nil).TotalVotingPower), arg0)
+}
+
+// Unseat mocks base method.
+func (m *MockRepo) Unseat(arg0 context.Context, arg1 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unseat", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unseat indicates an expected call of Unseat.
+func (mr *MockRepoMockRecorder) Unseat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unseat", reflect.TypeOf((*MockRepo)(nil).Unseat), arg0, arg1)
+}
+
+// UpdateConcludedVotes mocks base method.
+func (m *MockRepo) UpdateConcludedVotes(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConcludedVotes", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConcludedVotes indicates an expected call of UpdateConcludedVotes.
+func (mr *MockRepoMockRecorder) UpdateConcludedVotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConcludedVotes", reflect.TypeOf((*MockRepo)(nil).UpdateConcludedVotes), arg0, arg1)
+}
+
+// UpsertVotingList mocks base method.
+func (m *MockRepo) UpsertVotingList(arg0 context.Context, arg1 []models.VotingSetup) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertVotingList", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertVotingList indicates an expected call of UpsertVotingList.
+func (mr *MockRepoMockRecorder) UpsertVotingList(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertVotingList", reflect.TypeOf((*MockRepo)(nil).UpsertVotingList), arg0, arg1)
+}
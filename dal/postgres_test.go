@@ -0,0 +1,253 @@
+package dal_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/dal/repotest"
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	glogger "gorm.io/gorm/logger"
+)
+
+const (
+	// pgBinEnv - The directory of the initdb and pg_ctl binaries, else they are looked up in the PATH
+	// and in the Debian and Alpine layouts, then downloaded once into ~/.embedded-postgres-go
+	pgBinEnv = "NDAU_TEST_PG_BIN"
+	// pgRequiredEnv - Fail rather than skip the Postgres tests when the cluster cannot be started, so that
+	// CI does not pass without running them
+	pgRequiredEnv = "NDAU_TEST_PG_REQUIRED"
+)
+
+// ephemeralPostgres - A Postgres cluster in a temporary directory, started by the first test that needs
+// it and stopped by TestMain
+type ephemeralPostgres struct {
+	once     sync.Once
+	skip     string
+	err      error
+	bin      string
+	embedded *embeddedpostgres.EmbeddedPostgres
+	dir      string
+	port     int
+	admin    *gorm.DB
+	dbs      int64
+}
+
+var pg ephemeralPostgres
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	pg.stop()
+	os.Exit(code)
+}
+
+// findPgBin - The directory of initdb, empty when Postgres is not installed and has to be downloaded
+func findPgBin() string {
+	if dir := os.Getenv(pgBinEnv); dir != "" {
+		return dir
+	}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path)
+	}
+	matches, _ := filepath.Glob("/usr/lib/postgresql/*/bin/initdb")
+	alpine, _ := filepath.Glob("/usr/libexec/postgresql*/initdb")
+	matches = append(matches, alpine...)
+	if len(matches) == 0 {
+		return ""
+	}
+	return filepath.Dir(matches[len(matches)-1])
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (p *ephemeralPostgres) run(name string, args ...string) error {
+	var out bytes.Buffer
+	cmd := exec.Command(filepath.Join(p.bin, name), args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v\n%s", name, err, out.String())
+	}
+	return nil
+}
+
+func (p *ephemeralPostgres) dsn(name string) string {
+	return fmt.Sprintf("host=127.0.0.1 port=%d user=postgres password=postgres dbname=%s sslmode=disable", p.port, name)
+}
+
+// startInstalled - Runs the cluster with the binaries found on the machine
+func (p *ephemeralPostgres) startInstalled() error {
+	data := filepath.Join(p.dir, "data")
+	if err := p.run("initdb", "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync"); err != nil {
+		return err
+	}
+	// No fsync, the cluster is thrown away
+	options := fmt.Sprintf("-p %d -h 127.0.0.1 -k %s -F", p.port, p.dir)
+	return p.run("pg_ctl", "-D", data, "-l", filepath.Join(p.dir, "postgres.log"), "-o", options, "-w", "-t", "60", "start")
+}
+
+// startEmbedded - Runs the cluster with binaries downloaded from Maven Central, cached for the next runs
+func (p *ephemeralPostgres) startEmbedded() error {
+	var out bytes.Buffer
+	p.embedded = embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Version(embeddedpostgres.V15).
+		Port(uint32(p.port)).
+		RuntimePath(filepath.Join(p.dir, "runtime")).
+		DataPath(filepath.Join(p.dir, "data")).
+		StartTimeout(time.Minute).
+		StartParameters(map[string]string{"fsync": "off"}).
+		Logger(&out))
+	if err := p.embedded.Start(); err != nil {
+		p.embedded = nil
+		return fmt.Errorf("embedded Postgres: %v\n%s", err, out.String())
+	}
+	return nil
+}
+
+func (p *ephemeralPostgres) start() {
+	if testing.Short() {
+		p.skip = "the Postgres integration suite does not run in short mode"
+		return
+	}
+	if os.Geteuid() == 0 {
+		p.skip = "Postgres refuses to run as root"
+		return
+	}
+
+	if p.dir, p.err = os.MkdirTemp("", "dal-postgres-"); p.err != nil {
+		return
+	}
+	if p.port, p.err = freePort(); p.err != nil {
+		return
+	}
+	if p.bin = findPgBin(); p.bin != "" {
+		if p.err = p.startInstalled(); p.err != nil {
+			return
+		}
+	} else if err := p.startEmbedded(); err != nil {
+		// Most likely offline without an installed Postgres
+		p.skip = err.Error()
+		return
+	}
+
+	p.admin, p.err = gorm.Open(postgres.Open(p.dsn("postgres")), &gorm.Config{Logger: glogger.Discard})
+}
+
+func (p *ephemeralPostgres) stop() {
+	if p.dir == "" {
+		return
+	}
+	if p.admin != nil {
+		if sqlDB, err := p.admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	if p.embedded != nil {
+		if err := p.embedded.Stop(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	} else if p.bin != "" {
+		if err := p.run("pg_ctl", "-D", filepath.Join(p.dir, "data"), "-m", "immediate", "-w", "stop"); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	os.RemoveAll(p.dir)
+}
+
+// newPostgresDb - A repository on a new database of the cluster, not migrated yet
+func newPostgresDb(t *testing.T) *dal.Db {
+	t.Helper()
+
+	pg.once.Do(pg.start)
+	if pg.skip != "" {
+		if os.Getenv(pgRequiredEnv) != "" {
+			t.Fatalf("%s is set: %s", pgRequiredEnv, pg.skip)
+		}
+		t.Skip(pg.skip)
+	}
+	if pg.err != nil {
+		t.Fatalf("failed starting Postgres: %v", pg.err)
+	}
+
+	name := fmt.Sprintf("repotest_%d", atomic.AddInt64(&pg.dbs, 1))
+	if err := pg.admin.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Fatalf("failed creating the database %s: %v", name, err)
+	}
+
+	cfg := models.DefaultConfig()
+	cfg.ConnectionString = pg.dsn(name)
+	cfg.Database.LogLevel = "silent"
+	db, err := dal.NewDb(&cfg, &logger.NoopLogger{})
+	if err != nil {
+		t.Fatalf("NewDb() = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// openPostgres - A migrated repository on a new database of the cluster
+func openPostgres(t *testing.T) repotest.Repo {
+	t.Helper()
+
+	db := newPostgresDb(t)
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+	return db
+}
+
+func TestPostgresConformance(t *testing.T) {
+	repotest.Run(t, openPostgres)
+}
+
+func TestMigrateRejectsDuplicateVotes(t *testing.T) {
+	db := newPostgresDb(t)
+
+	// A votes table of a deployment older than the unique index, with an account that voted twice
+	for _, stmt := range []string{
+		"CREATE TABLE public.votes (id bigserial PRIMARY KEY, proposal_id bigint NOT NULL, user_address text NOT NULL, choice text NOT NULL)",
+		"INSERT INTO public.votes (proposal_id, user_address, choice) VALUES (1, 'a', 'yes'), (1, 'a', 'no'), (1, 'b', 'yes')",
+	} {
+		if err := db.Client.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed preparing the votes table: %v", err)
+		}
+	}
+
+	err := db.Migrate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "several votes of an account on a proposal") {
+		t.Fatalf("Migrate() = %v, want the duplicate votes reported", err)
+	}
+	var applied int64
+	if err := db.Client.Table("public.schema_version").Count(&applied).Error; err != nil || applied != 0 {
+		t.Errorf("applied migrations = %d, %v, want none", applied, err)
+	}
+
+	// Once the operator kept a single vote the migration goes through
+	if err := db.Client.Exec("DELETE FROM public.votes WHERE user_address = 'a' AND choice = 'no'").Error; err != nil {
+		t.Fatalf("failed removing the duplicate vote: %v", err)
+	}
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate() after removing the duplicate = %v", err)
+	}
+}
@@ -0,0 +1,876 @@
+// Package repotest is the conformance suite of the dal.Repo implementations: every implementation
+// must pass it, so that the in-memory and SQLite repositories can stand in for Postgres.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// Repo - A repository under test. Proposals are written by the DAO front end, the suite needs
+// to create them as well.
+type Repo interface {
+	dal.Repo
+	CreateProposal(ctx context.Context, proposal *models.Proposal) error
+}
+
+// Opener - An empty repository with its schema, closed by the caller's cleanup
+type Opener func(t *testing.T) Repo
+
+// base is the time of the fixtures, in UTC and whole seconds so that every implementation stores it exactly
+var base = time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// Run - Run the whole suite, each test on a new repository
+func Run(t *testing.T, open Opener) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo Repo)
+	}{
+		{"Migrate", testMigrate},
+		{"Accounts", testAccounts},
+		{"Unseat", testUnseat},
+		{"SeatedAccounts", testSeatedAccounts},
+		{"Stats", testStats},
+		{"Proposals", testProposals},
+		{"Votes", testVotes},
+		{"VoteProposals", testVoteProposals},
+		{"ConcludedVotes", testConcludedVotes},
+		{"FreezeManyVotes", testFreezeManyVotes},
+		{"Delegations", testDelegations},
+		{"Exclusions", testExclusions},
+		{"Snapshots", testSnapshots},
+		{"SnapshotInputs", testSnapshotInputs},
+		{"DeleteSnapshots", testDeleteSnapshots},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+// ctx - The context of a run, with its tracking number
+func ctx() context.Context {
+	return context.WithValue(context.Background(), "tracking_number", "repotest")
+}
+
+func seatedAt(days int) time.Time {
+	return base.AddDate(0, 0, days)
+}
+
+// account - An eligible account
+func account(address string, votes float64, seat time.Time) models.VotingSetup {
+	return models.VotingSetup{
+		Address:           address,
+		CurrencySeatDate:  seat,
+		Votes:             votes,
+		EffectiveVotes:    votes,
+		Eligible:          true,
+		EligibilityReason: "regular",
+	}
+}
+
+func mustUpsert(t *testing.T, repo Repo, accounts ...models.VotingSetup) {
+	t.Helper()
+	if err := repo.UpsertVotingList(ctx(), accounts); err != nil {
+		t.Fatalf("UpsertVotingList() = %v", err)
+	}
+}
+
+func addresses(accounts []models.VotingSetup) []string {
+	list := []string{}
+	for _, account := range accounts {
+		list = append(list, account.Address)
+	}
+	return list
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testMigrate(t *testing.T, repo Repo) {
+	if err := repo.Ping(ctx()); err != nil {
+		t.Errorf("Ping() = %v", err)
+	}
+	if err := repo.Migrate(ctx()); err != nil {
+		t.Errorf("Migrate() on a migrated repository = %v", err)
+	}
+}
+
+func testAccounts(t *testing.T, repo Repo) {
+	mustUpsert(t, repo, account("b", 20, seatedAt(2)), account("a", 10, seatedAt(1)))
+
+	accounts, err := repo.ListAccount(ctx())
+	if err != nil {
+		t.Fatalf("ListAccount() = %v", err)
+	}
+	if got := addresses(accounts); !equalStrings(got, []string{"a", "b"}) {
+		t.Errorf("ListAccount() = %v, want [a b]", got)
+	}
+	if !accounts[0].CurrencySeatDate.Equal(seatedAt(1)) || accounts[0].Votes != 10 {
+		t.Errorf("ListAccount()[0] = %+v", accounts[0])
+	}
+
+	// An upsert updates every column of the existing accounts
+	updated := account("a", 15, seatedAt(3))
+	updated.EffectiveVotes = 5
+	updated.Eligible = false
+	updated.EligibilityReason = models.AccountClassExcluded
+	mustUpsert(t, repo, updated)
+
+	got, err := repo.GetAccount(ctx(), "a")
+	if err != nil {
+		t.Fatalf("GetAccount() = %v", err)
+	}
+	if got.Votes != 15 || got.EffectiveVotes != 5 || got.Eligible || got.EligibilityReason != models.AccountClassExcluded || !got.CurrencySeatDate.Equal(seatedAt(3)) {
+		t.Errorf("GetAccount() after an upsert = %+v, want %+v", got, updated)
+	}
+
+	if _, err := repo.GetAccount(ctx(), "missing"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("GetAccount() of a missing account = %v, want %v", err, dal.ErrNotFound)
+	}
+
+	total, err := repo.TotalVotingPower(ctx())
+	if err != nil {
+		t.Fatalf("TotalVotingPower() = %v", err)
+	}
+	if total != 35 {
+		t.Errorf("TotalVotingPower() = %v, want 35", total)
+	}
+}
+
+func testUnseat(t *testing.T, repo Repo) {
+	mustUpsert(t, repo, account("a", 10, seatedAt(1)), account("b", 20, seatedAt(2)))
+
+	if err := repo.Unseat(ctx(), []string{}); err != nil {
+		t.Errorf("Unseat() of no address = %v", err)
+	}
+	if err := repo.Unseat(ctx(), []string{"a", "missing"}); err != nil {
+		t.Fatalf("Unseat() = %v", err)
+	}
+
+	a, err := repo.GetAccount(ctx(), "a")
+	if err != nil {
+		t.Fatalf("GetAccount() = %v", err)
+	}
+	if a.Votes != 0 || a.EffectiveVotes != 0 || a.CurrencySeatDate.Year() != 1 || a.Seated() {
+		t.Errorf("unseated account = %+v, want no votes and a 0001-01-01 seat date", a)
+	}
+
+	b, err := repo.GetAccount(ctx(), "b")
+	if err != nil {
+		t.Fatalf("GetAccount() = %v", err)
+	}
+	if b.Votes != 20 || !b.CurrencySeatDate.Equal(seatedAt(2)) {
+		t.Errorf("account left alone = %+v", b)
+	}
+
+	if _, err := repo.GetAccount(ctx(), "missing"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("Unseat() created the missing account: %v", err)
+	}
+}
+
+func testSeatedAccounts(t *testing.T, repo Repo) {
+	excluded := account("x", 100, seatedAt(0))
+	excluded.Eligible = false
+	mustUpsert(t, repo,
+		account("a", 30, seatedAt(3)),
+		account("b", 10, seatedAt(1)),
+		account("c", 30, seatedAt(2)),
+		account("d", 20, models.SeatedSince.AddDate(0, 0, -1)),
+		excluded,
+	)
+
+	tests := []struct {
+		query models.AccountQuery
+		want  []string
+	}{
+		{models.AccountQuery{}, []string{"b", "a", "c"}},
+		{models.AccountQuery{Desc: true}, []string{"a", "c", "b"}},
+		{models.AccountQuery{SortBy: models.SortBySeatDate}, []string{"b", "c", "a"}},
+		{models.AccountQuery{SortBy: models.SortBySeatDate, Desc: true}, []string{"a", "c", "b"}},
+		{models.AccountQuery{Limit: 2, Desc: true}, []string{"a", "c"}},
+		{models.AccountQuery{Limit: 2, Offset: 2, Desc: true}, []string{"b"}},
+		{models.AccountQuery{Offset: 5}, []string{}},
+	}
+	for _, tt := range tests {
+		accounts, total, err := repo.ListSeatedAccounts(ctx(), tt.query)
+		if err != nil {
+			t.Fatalf("ListSeatedAccounts(%+v) = %v", tt.query, err)
+		}
+		if got := addresses(accounts); !equalStrings(got, tt.want) {
+			t.Errorf("ListSeatedAccounts(%+v) = %v, want %v", tt.query, got, tt.want)
+		}
+		if total != 3 {
+			t.Errorf("ListSeatedAccounts(%+v) total = %d, want 3", tt.query, total)
+		}
+	}
+}
+
+func testStats(t *testing.T, repo Repo) {
+	// The accounts that are not seated have the most votes, they are left out of the top holders
+	excluded := account("x", 100, seatedAt(0))
+	excluded.Eligible = false
+	unseated := account("u", 50, models.SeatedSince.AddDate(0, 0, -1))
+	mustUpsert(t, repo, account("a", 10, seatedAt(1)), account("b", 30, seatedAt(2)), account("c", 10, seatedAt(3)), excluded, unseated)
+
+	stats, err := repo.GetStats(ctx(), 2)
+	if err != nil {
+		t.Fatalf("GetStats() = %v", err)
+	}
+	if stats.SeatCount != 3 || stats.TotalVotes != 200 {
+		t.Errorf("GetStats() = %d seats and %v votes, want 3 and 200", stats.SeatCount, stats.TotalVotes)
+	}
+	if got := addresses(stats.TopHolders); !equalStrings(got, []string{"b", "a"}) {
+		t.Errorf("GetStats() top holders = %v, want [b a]", got)
+	}
+	stats, err = repo.GetStats(ctx(), 10)
+	if err != nil {
+		t.Fatalf("GetStats(10) = %v", err)
+	}
+	if got := addresses(stats.TopHolders); !equalStrings(got, []string{"b", "a", "c"}) {
+		t.Errorf("GetStats(10) top holders = %v, want the 3 seated accounts", got)
+	}
+
+	stats, err = repo.GetStats(ctx(), 0)
+	if err != nil {
+		t.Fatalf("GetStats(0) = %v", err)
+	}
+	if len(stats.TopHolders) != 0 {
+		t.Errorf("GetStats(0) top holders = %v, want none", addresses(stats.TopHolders))
+	}
+}
+
+// mustCreateProposals - Pending, open, concluded by date and concluded by flag proposals, in that order
+func mustCreateProposals(t *testing.T, repo Repo) []models.Proposal {
+	t.Helper()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	proposals := []models.Proposal{
+		{IsApproved: false, ClosingDate: now.Add(4 * time.Hour)},
+		{IsApproved: true, ClosingDate: now.Add(3 * time.Hour)},
+		{IsApproved: true, ClosingDate: now.Add(-time.Hour)},
+		{IsApproved: true, ClosingDate: now.Add(2 * time.Hour), Concluded: true},
+	}
+	for i := range proposals {
+		if err := repo.CreateProposal(ctx(), &proposals[i]); err != nil {
+			t.Fatalf("CreateProposal() = %v", err)
+		}
+	}
+	return proposals
+}
+
+func proposalIDs(proposals []models.Proposal) []int64 {
+	ids := []int64{}
+	for _, proposal := range proposals {
+		ids = append(ids, proposal.ProposalID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testProposals(t *testing.T, repo Repo) {
+	p := mustCreateProposals(t, repo)
+	pending, open, closed, concluded := p[0].ProposalID, p[1].ProposalID, p[2].ProposalID, p[3].ProposalID
+
+	active, err := repo.ListActiveProposal(ctx())
+	if err != nil {
+		t.Fatalf("ListActiveProposal() = %v", err)
+	}
+	if got, want := proposalIDs(active), []int64{closed, open}; !equalIDs(got, want) {
+		t.Errorf("ListActiveProposal() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		status string
+		want   []int64
+	}{
+		{"", []int64{closed, concluded, open, pending}},
+		{models.ProposalStatusPending, []int64{pending}},
+		{models.ProposalStatusOpen, []int64{open}},
+		{models.ProposalStatusConcluded, []int64{closed, concluded}},
+	}
+	for _, tt := range tests {
+		proposals, err := repo.ListProposals(ctx(), tt.status)
+		if err != nil {
+			t.Fatalf("ListProposals(%q) = %v", tt.status, err)
+		}
+		if got := proposalIDs(proposals); !equalIDs(got, tt.want) {
+			t.Errorf("ListProposals(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+	if _, err := repo.ListProposals(ctx(), "unknown"); err == nil {
+		t.Error("ListProposals() of an unknown status = nil, want an error")
+	}
+
+	proposal, err := repo.GetProposal(ctx(), concluded)
+	if err != nil {
+		t.Fatalf("GetProposal() = %v", err)
+	}
+	if !proposal.IsApproved || !proposal.Concluded || !proposal.ClosingDate.Equal(p[3].ClosingDate) {
+		t.Errorf("GetProposal() = %+v, want %+v", proposal, p[3])
+	}
+	if _, err := repo.GetProposal(ctx(), concluded+100); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("GetProposal() of a missing proposal = %v, want %v", err, dal.ErrNotFound)
+	}
+}
+
+func mustCast(t *testing.T, repo Repo, proposalID int64, address, choice string, at time.Time) models.Vote {
+	t.Helper()
+	vote := models.Vote{ProposalID: proposalID, UserAddress: address, Choice: choice, CastAt: at}
+	if err := repo.CastVote(ctx(), &vote); err != nil {
+		t.Fatalf("CastVote(%s, %s) = %v", address, choice, err)
+	}
+	return vote
+}
+
+func testVotes(t *testing.T, repo Repo) {
+	proposalID := mustCreateProposals(t, repo)[1].ProposalID
+	mustUpsert(t, repo, account("a", 10, seatedAt(1)), account("b", 20, seatedAt(2)), account("c", 40, seatedAt(3)))
+
+	first := mustCast(t, repo, proposalID, "a", models.ChoiceYes, base)
+	if first.ID == 0 {
+		t.Error("CastVote() did not set the vote id")
+	}
+	mustCast(t, repo, proposalID, "b", models.ChoiceNo, base.Add(time.Minute))
+	mustCast(t, repo, proposalID, "c", models.ChoiceAbstain, base.Add(2*time.Minute))
+
+	// A newer vote changes the choice, an older one is stale
+	mustCast(t, repo, proposalID, "a", models.ChoiceNo, base.Add(3*time.Minute))
+	stale := models.Vote{ProposalID: proposalID, UserAddress: "a", Choice: models.ChoiceYes, CastAt: base.Add(3 * time.Minute)}
+	if err := repo.CastVote(ctx(), &stale); !errors.Is(err, dal.ErrStaleVote) {
+		t.Errorf("CastVote() of a stale vote = %v, want %v", err, dal.ErrStaleVote)
+	}
+
+	vote, err := repo.GetVote(ctx(), proposalID, "a")
+	if err != nil {
+		t.Fatalf("GetVote() = %v", err)
+	}
+	if vote.ID != first.ID || vote.Choice != models.ChoiceNo || !vote.CastAt.Equal(base.Add(3*time.Minute)) || vote.ConcludedVotes != nil {
+		t.Errorf("GetVote() = %+v, want the changed vote %d", vote, first.ID)
+	}
+	if _, err := repo.GetVote(ctx(), proposalID, "missing"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("GetVote() of a missing vote = %v, want %v", err, dal.ErrNotFound)
+	}
+
+	votes, err := repo.ListVotes(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("ListVotes() = %v", err)
+	}
+	got := []string{}
+	for _, vote := range votes {
+		got = append(got, vote.UserAddress)
+	}
+	if !equalStrings(got, []string{"b", "c", "a"}) {
+		t.Errorf("ListVotes() = %v, want [b c a] by cast time", got)
+	}
+
+	tally, err := repo.LiveTally(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("LiveTally() = %v", err)
+	}
+	if want := (models.Tally{No: 30, Abstain: 40}); *tally != want {
+		t.Errorf("LiveTally() = %+v, want %+v", *tally, want)
+	}
+}
+
+func testVoteProposals(t *testing.T, repo Repo) {
+	proposals := mustCreateProposals(t, repo)
+	first, second := proposals[1].ProposalID, proposals[2].ProposalID
+	mustUpsert(t, repo, account("a", 10, seatedAt(1)))
+
+	// A vote without a cast time is cast now
+	before := time.Now().Add(-time.Second)
+	vote := models.Vote{ProposalID: first, UserAddress: "a", Choice: models.ChoiceYes}
+	if err := repo.CastVote(ctx(), &vote); err != nil {
+		t.Fatalf("CastVote() = %v", err)
+	}
+	if vote.CastAt.Before(before) || vote.CastAt.After(time.Now().Add(time.Second)) {
+		t.Errorf("CastVote() cast the vote at %v, want now", vote.CastAt)
+	}
+
+	// The votes of an account on different proposals are independent
+	mustCast(t, repo, second, "a", models.ChoiceNo, base)
+	got, err := repo.GetVote(ctx(), first, "a")
+	if err != nil {
+		t.Fatalf("GetVote() = %v", err)
+	}
+	if got.ID != vote.ID || got.Choice != models.ChoiceYes {
+		t.Errorf("GetVote() = %+v, want the vote %d on the first proposal", got, vote.ID)
+	}
+	votes, err := repo.ListVotes(ctx(), second)
+	if err != nil {
+		t.Fatalf("ListVotes() = %v", err)
+	}
+	if len(votes) != 1 || votes[0].ID == vote.ID || votes[0].Choice != models.ChoiceNo {
+		t.Errorf("ListVotes() of the second proposal = %+v, want its own vote", votes)
+	}
+	if votes, err := repo.ListVotes(ctx(), proposals[0].ProposalID); err != nil || len(votes) != 0 {
+		t.Errorf("ListVotes() of a proposal without votes = %v, %v, want none", votes, err)
+	}
+}
+
+func testConcludedVotes(t *testing.T, repo Repo) {
+	proposals := mustCreateProposals(t, repo)
+	proposalID, other := proposals[2].ProposalID, proposals[1].ProposalID
+	mustUpsert(t, repo, account("a", 10, seatedAt(1)), account("b", 20, seatedAt(2)), account("d", 5, seatedAt(3)))
+	if err := repo.SetDelegation(ctx(), "d", "a"); err != nil {
+		t.Fatalf("SetDelegation() = %v", err)
+	}
+	mustCast(t, repo, proposalID, "a", models.ChoiceYes, base)
+	mustCast(t, repo, proposalID, "b", models.ChoiceNo, base)
+	mustCast(t, repo, other, "b", models.ChoiceYes, base)
+
+	if err := repo.UpdateConcludedVotes(ctx(), proposalID); err != nil {
+		t.Fatalf("UpdateConcludedVotes() = %v", err)
+	}
+	tally, err := repo.ConcludedTally(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("ConcludedTally() = %v", err)
+	}
+	if want := (models.Tally{Yes: 15, No: 20}); *tally != want {
+		t.Errorf("ConcludedTally() = %+v, want %+v with the delegated power", *tally, want)
+	}
+	if tally, err := repo.ConcludedTally(ctx(), other); err != nil || *tally != (models.Tally{}) {
+		t.Errorf("ConcludedTally() of another proposal = %+v, %v, want nothing frozen", tally, err)
+	}
+	assertConcludedTotal(t, repo, proposalID, 35)
+
+	// A frozen proposal is concluded and left out of the later runs
+	if proposal, err := repo.GetProposal(ctx(), proposalID); err != nil || !proposal.Concluded {
+		t.Errorf("GetProposal() of a frozen proposal = %+v, %v, want it concluded", proposal, err)
+	}
+	active, err := repo.ListActiveProposal(ctx())
+	if err != nil {
+		t.Fatalf("ListActiveProposal() = %v", err)
+	}
+	for _, proposal := range active {
+		if proposal.ProposalID == proposalID {
+			t.Errorf("ListActiveProposal() = %v, want the frozen proposal %d left out", active, proposalID)
+		}
+	}
+
+	// Frozen votes can neither change nor be frozen again
+	late := models.Vote{ProposalID: proposalID, UserAddress: "a", Choice: models.ChoiceNo, CastAt: base.Add(time.Hour)}
+	if err := repo.CastVote(ctx(), &late); !errors.Is(err, dal.ErrVoteFrozen) {
+		t.Errorf("CastVote() on a frozen vote = %v, want %v", err, dal.ErrVoteFrozen)
+	}
+	mustUpsert(t, repo, account("a", 100, seatedAt(1)))
+	if err := repo.UpdateConcludedVotes(ctx(), proposalID); err != nil {
+		t.Fatalf("UpdateConcludedVotes() = %v", err)
+	}
+	vote, err := repo.GetVote(ctx(), proposalID, "a")
+	if err != nil {
+		t.Fatalf("GetVote() = %v", err)
+	}
+	if vote.ConcludedVotes == nil || *vote.ConcludedVotes != 15 {
+		t.Errorf("frozen power after a second UpdateConcludedVotes() = %v, want 15", vote.ConcludedVotes)
+	}
+	assertConcludedTotal(t, repo, proposalID, 35)
+
+	// Reconcluding freezes the current power
+	if err := repo.ReconcludeProposal(ctx(), proposalID); err != nil {
+		t.Fatalf("ReconcludeProposal() = %v", err)
+	}
+	tally, err = repo.ConcludedTally(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("ConcludedTally() = %v", err)
+	}
+	if want := (models.Tally{Yes: 105, No: 20}); *tally != want {
+		t.Errorf("ConcludedTally() after ReconcludeProposal() = %+v, want %+v", *tally, want)
+	}
+	assertConcludedTotal(t, repo, proposalID, 125)
+}
+
+func testFreezeManyVotes(t *testing.T, repo Repo) {
+	proposals := mustCreateProposals(t, repo)
+	proposalID, concluded := proposals[2].ProposalID, proposals[3].ProposalID
+
+	// More voters than a statement freezes, each with a delegator
+	const voters = 1234
+	accounts := []models.VotingSetup{}
+	for i := 0; i < voters; i++ {
+		accounts = append(accounts, account(fmt.Sprintf("v%04d", i), float64(i), seatedAt(1)), account(fmt.Sprintf("d%04d", i), 1, seatedAt(1)))
+	}
+	mustUpsert(t, repo, accounts...)
+	for i := 0; i < voters; i++ {
+		if err := repo.SetDelegation(ctx(), fmt.Sprintf("d%04d", i), fmt.Sprintf("v%04d", i)); err != nil {
+			t.Fatalf("SetDelegation() = %v", err)
+		}
+		mustCast(t, repo, proposalID, fmt.Sprintf("v%04d", i), models.ChoiceYes, base)
+	}
+	mustCast(t, repo, concluded, "v0001", models.ChoiceYes, base)
+
+	if err := repo.UpdateConcludedVotes(ctx(), proposalID); err != nil {
+		t.Fatalf("UpdateConcludedVotes() = %v", err)
+	}
+	votes, err := repo.ListVotes(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("ListVotes() = %v", err)
+	}
+	for _, vote := range votes {
+		var i int
+		fmt.Sscanf(vote.UserAddress, "v%04d", &i)
+		if vote.ConcludedVotes == nil || *vote.ConcludedVotes != float64(i+1) {
+			t.Fatalf("frozen power of %s = %v, want %d", vote.UserAddress, vote.ConcludedVotes, i+1)
+		}
+	}
+
+	// A proposal already concluded is not frozen again
+	if err := repo.UpdateConcludedVotes(ctx(), concluded); err != nil {
+		t.Fatalf("UpdateConcludedVotes() = %v", err)
+	}
+	if vote, err := repo.GetVote(ctx(), concluded, "v0001"); err != nil || vote.ConcludedVotes != nil {
+		t.Errorf("vote on a concluded proposal = %+v, %v, want it left unfrozen", vote, err)
+	}
+}
+
+// assertConcludedTotal - Check the total voting power recorded when the proposal was frozen
+func assertConcludedTotal(t *testing.T, repo Repo, proposalID int64, want float64) {
+	t.Helper()
+
+	proposal, err := repo.GetProposal(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("GetProposal() = %v", err)
+	}
+	if proposal.ConcludedTotalPower == nil || *proposal.ConcludedTotalPower != want {
+		t.Errorf("concluded total power = %v, want %v", proposal.ConcludedTotalPower, want)
+	}
+}
+
+func testDelegations(t *testing.T, repo Repo) {
+	proposalID := mustCreateProposals(t, repo)[1].ProposalID
+	mustUpsert(t, repo, account("a", 10, seatedAt(1)), account("b", 20, seatedAt(2)), account("c", 40, seatedAt(3)))
+
+	if err := repo.SetDelegation(ctx(), "a", "a"); err == nil {
+		t.Error("SetDelegation() to itself = nil, want an error")
+	}
+	for _, d := range [][2]string{{"c", "a"}, {"b", "a"}, {"c", "b"}} {
+		if err := repo.SetDelegation(ctx(), d[0], d[1]); err != nil {
+			t.Fatalf("SetDelegation(%s, %s) = %v", d[0], d[1], err)
+		}
+	}
+
+	delegations, err := repo.ListDelegations(ctx())
+	if err != nil {
+		t.Fatalf("ListDelegations() = %v", err)
+	}
+	got := []string{}
+	for _, d := range delegations {
+		got = append(got, d.Delegator+">"+d.Delegate)
+	}
+	if !equalStrings(got, []string{"b>a", "c>b"}) {
+		t.Errorf("ListDelegations() = %v, want [b>a c>b]", got)
+	}
+
+	// c delegates through b to a, who is the only voter
+	mustCast(t, repo, proposalID, "a", models.ChoiceYes, base)
+	tally, err := repo.LiveTally(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("LiveTally() = %v", err)
+	}
+	if want := (models.Tally{Yes: 70}); *tally != want {
+		t.Errorf("LiveTally() = %+v, want %+v", *tally, want)
+	}
+
+	// A delegator that votes keeps its own power, the power delegated through it still goes to the
+	// end of the chain as in the effective voting power
+	mustCast(t, repo, proposalID, "b", models.ChoiceNo, base)
+	tally, err = repo.LiveTally(ctx(), proposalID)
+	if err != nil {
+		t.Fatalf("LiveTally() = %v", err)
+	}
+	if want := (models.Tally{Yes: 50, No: 20}); *tally != want {
+		t.Errorf("LiveTally() = %+v, want %+v", *tally, want)
+	}
+
+	if err := repo.RevokeDelegation(ctx(), "c"); err != nil {
+		t.Fatalf("RevokeDelegation() = %v", err)
+	}
+	if err := repo.RevokeDelegation(ctx(), "c"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("RevokeDelegation() of a missing delegation = %v, want %v", err, dal.ErrNotFound)
+	}
+	delegations, err = repo.ListDelegations(ctx())
+	if err != nil || len(delegations) != 1 {
+		t.Errorf("ListDelegations() after a revocation = %v, %v, want 1 delegation", delegations, err)
+	}
+}
+
+func testExclusions(t *testing.T, repo Repo) {
+	for _, e := range []models.Exclusion{{Address: "b", Reason: "exchange"}, {Address: "a", Reason: "treasury"}, {Address: "b", Reason: "hot wallet"}} {
+		e := e
+		if err := repo.AddExclusion(ctx(), &e, "alice"); err != nil {
+			t.Fatalf("AddExclusion(%s) = %v", e.Address, err)
+		}
+		if e.CreatedBy != "alice" || e.CreatedAt.IsZero() {
+			t.Errorf("AddExclusion() = %+v, want the actor and the time recorded", e)
+		}
+	}
+
+	exclusions, err := repo.ListExclusions(ctx())
+	if err != nil {
+		t.Fatalf("ListExclusions() = %v", err)
+	}
+	if len(exclusions) != 2 || exclusions[0].Address != "a" || exclusions[1].Reason != "hot wallet" || exclusions[1].CreatedBy != "alice" {
+		t.Errorf("ListExclusions() = %+v, want a and b with the updated reason", exclusions)
+	}
+
+	if err := repo.RemoveExclusion(ctx(), "a", "audited", "bob"); err != nil {
+		t.Fatalf("RemoveExclusion() = %v", err)
+	}
+	if err := repo.RemoveExclusion(ctx(), "a", "again", "bob"); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("RemoveExclusion() of a missing exclusion = %v, want %v", err, dal.ErrNotFound)
+	}
+
+	audits, err := repo.ListExclusionAudits(ctx(), "", 0)
+	if err != nil {
+		t.Fatalf("ListExclusionAudits() = %v", err)
+	}
+	got := []string{}
+	for _, audit := range audits {
+		got = append(got, audit.Action+" "+audit.Address+" "+audit.Actor)
+	}
+	if want := []string{"remove a bob", "add b alice", "add a alice", "add b alice"}; !equalStrings(got, want) {
+		t.Errorf("ListExclusionAudits() = %v, want %v, the failed removal is not audited", got, want)
+	}
+
+	audits, err = repo.ListExclusionAudits(ctx(), "b", 1)
+	if err != nil {
+		t.Fatalf("ListExclusionAudits() = %v", err)
+	}
+	if len(audits) != 1 || audits[0].Reason != "hot wallet" {
+		t.Errorf("ListExclusionAudits(b, 1) = %+v, want the latest change of b", audits)
+	}
+}
+
+func testSnapshots(t *testing.T, repo Repo) {
+	snapshot := models.Snapshot{
+		TrackingNumber: "repotest",
+		Policy:         models.DefaultPolicyName,
+		Status:         models.SnapshotRunning,
+		Network:        "testnet",
+		StartedAt:      base,
+	}
+	if err := repo.CreateSnapshot(ctx(), &snapshot); err != nil {
+		t.Fatalf("CreateSnapshot() = %v", err)
+	}
+	if snapshot.ID == 0 {
+		t.Fatal("CreateSnapshot() did not set the snapshot id")
+	}
+
+	accounts := []models.SnapshotAccount{
+		{Address: "b", Balance: 200, CurrencySeatDate: seatedAt(2), Class: "regular", Eligible: true, Votes: 2, EffectiveVotes: 2},
+		{Address: "a", Balance: 100, CurrencySeatDate: seatedAt(1), Class: models.AccountClassExcluded},
+	}
+	if err := repo.SaveSnapshotAccounts(ctx(), snapshot.ID, nil); err != nil {
+		t.Errorf("SaveSnapshotAccounts() of no account = %v", err)
+	}
+	if err := repo.SaveSnapshotAccounts(ctx(), snapshot.ID, accounts); err != nil {
+		t.Fatalf("SaveSnapshotAccounts() = %v", err)
+	}
+	if err := repo.SaveSnapshotAccounts(ctx(), snapshot.ID, accounts[:1]); err == nil {
+		t.Error("SaveSnapshotAccounts() of an account already saved = nil, want an error")
+	}
+
+	snapshot.Status = models.SnapshotCompleted
+	snapshot.ChainTotalNdau = 300
+	if err := repo.FinishSnapshot(ctx(), &snapshot); err != nil {
+		t.Fatalf("FinishSnapshot() = %v", err)
+	}
+
+	got, err := repo.GetSnapshot(ctx(), snapshot.ID)
+	if err != nil {
+		t.Fatalf("GetSnapshot() = %v", err)
+	}
+	if got.Status != models.SnapshotCompleted || got.ChainTotalNdau != 300 || got.FinishedAt == nil || got.ParentID != nil ||
+		got.Network != "testnet" || got.Policy != models.DefaultPolicyName || !got.StartedAt.Equal(base) {
+		t.Errorf("GetSnapshot() = %+v", got)
+	}
+	if _, err := repo.GetSnapshot(ctx(), snapshot.ID+100); !errors.Is(err, dal.ErrNotFound) {
+		t.Errorf("GetSnapshot() of a missing snapshot = %v, want %v", err, dal.ErrNotFound)
+	}
+
+	stored, err := repo.ListSnapshotAccounts(ctx(), snapshot.ID)
+	if err != nil {
+		t.Fatalf("ListSnapshotAccounts() = %v", err)
+	}
+	if len(stored) != 2 || stored[0].Address != "a" || stored[1].SnapshotID != snapshot.ID || stored[1].Balance != 200 ||
+		!stored[1].CurrencySeatDate.Equal(seatedAt(2)) || !stored[1].Eligible || stored[1].EffectiveVotes != 2 {
+		t.Errorf("ListSnapshotAccounts() = %+v", stored)
+	}
+
+	derived := models.Snapshot{
+		TrackingNumber: "repotest",
+		ParentID:       &snapshot.ID,
+		Policy:         "other",
+		Status:         models.SnapshotRunning,
+		StartedAt:      base,
+	}
+	if err := repo.CreateSnapshot(ctx(), &derived); err != nil {
+		t.Fatalf("CreateSnapshot() of a derived snapshot = %v", err)
+	}
+	got, err = repo.GetSnapshot(ctx(), derived.ID)
+	if err != nil {
+		t.Fatalf("GetSnapshot() = %v", err)
+	}
+	if got.ParentID == nil || *got.ParentID != snapshot.ID {
+		t.Errorf("GetSnapshot() of a derived snapshot = %+v, want parent %d", got, snapshot.ID)
+	}
+	if accounts, err := repo.ListSnapshotAccounts(ctx(), derived.ID); err != nil || len(accounts) != 0 {
+		t.Errorf("ListSnapshotAccounts() of a new snapshot = %v, %v, want none", accounts, err)
+	}
+}
+
+func testSnapshotInputs(t *testing.T, repo Repo) {
+	snapshot := models.Snapshot{TrackingNumber: "repotest", Policy: models.DefaultPolicyName, Status: models.SnapshotRunning, StartedAt: base}
+	if err := repo.CreateSnapshot(ctx(), &snapshot); err != nil {
+		t.Fatalf("CreateSnapshot() = %v", err)
+	}
+
+	delegations := []models.SnapshotDelegation{{Delegator: "c", Delegate: "a"}, {Delegator: "b", Delegate: "a"}}
+	if err := repo.SaveSnapshotDelegations(ctx(), snapshot.ID, nil); err != nil {
+		t.Errorf("SaveSnapshotDelegations() of no delegation = %v", err)
+	}
+	if err := repo.SaveSnapshotDelegations(ctx(), snapshot.ID, delegations); err != nil {
+		t.Fatalf("SaveSnapshotDelegations() = %v", err)
+	}
+	if err := repo.SaveSnapshotDelegations(ctx(), snapshot.ID, delegations[:1]); err == nil {
+		t.Error("SaveSnapshotDelegations() of a delegator already saved = nil, want an error")
+	}
+	exclusions := []models.SnapshotExclusion{{Address: "z", Reason: "treasury"}, {Address: "y", Reason: "exchange"}}
+	if err := repo.SaveSnapshotExclusions(ctx(), snapshot.ID, exclusions); err != nil {
+		t.Fatalf("SaveSnapshotExclusions() = %v", err)
+	}
+	if err := repo.SaveSnapshotExclusions(ctx(), snapshot.ID, exclusions[:1]); err == nil {
+		t.Error("SaveSnapshotExclusions() of an address already saved = nil, want an error")
+	}
+
+	// The current tables are not part of the snapshot
+	if err := repo.SetDelegation(ctx(), "d", "a"); err != nil {
+		t.Fatalf("SetDelegation() = %v", err)
+	}
+
+	storedDelegations, err := repo.ListSnapshotDelegations(ctx(), snapshot.ID)
+	if err != nil {
+		t.Fatalf("ListSnapshotDelegations() = %v", err)
+	}
+	if len(storedDelegations) != 2 || storedDelegations[0].Delegator != "b" || storedDelegations[1].Delegate != "a" ||
+		storedDelegations[1].SnapshotID != snapshot.ID {
+		t.Errorf("ListSnapshotDelegations() = %+v", storedDelegations)
+	}
+	storedExclusions, err := repo.ListSnapshotExclusions(ctx(), snapshot.ID)
+	if err != nil {
+		t.Fatalf("ListSnapshotExclusions() = %v", err)
+	}
+	if len(storedExclusions) != 2 || storedExclusions[0].Address != "y" || storedExclusions[1].Reason != "treasury" ||
+		storedExclusions[0].SnapshotID != snapshot.ID {
+		t.Errorf("ListSnapshotExclusions() = %+v", storedExclusions)
+	}
+
+	if delegations, err := repo.ListSnapshotDelegations(ctx(), snapshot.ID+100); err != nil || len(delegations) != 0 {
+		t.Errorf("ListSnapshotDelegations() of a missing snapshot = %v, %v, want none", delegations, err)
+	}
+	if exclusions, err := repo.ListSnapshotExclusions(ctx(), snapshot.ID+100); err != nil || len(exclusions) != 0 {
+		t.Errorf("ListSnapshotExclusions() of a missing snapshot = %v, %v, want none", exclusions, err)
+	}
+}
+
+func testDeleteSnapshots(t *testing.T, repo Repo) {
+	create := func(status string, parent *int64, startedAt time.Time) models.Snapshot {
+		t.Helper()
+		snapshot := models.Snapshot{TrackingNumber: "repotest", ParentID: parent, Policy: models.DefaultPolicyName, Status: models.SnapshotRunning, StartedAt: startedAt}
+		if err := repo.CreateSnapshot(ctx(), &snapshot); err != nil {
+			t.Fatalf("CreateSnapshot() = %v", err)
+		}
+		if err := repo.SaveSnapshotAccounts(ctx(), snapshot.ID, []models.SnapshotAccount{{Address: "a", CurrencySeatDate: base, Class: "regular"}}); err != nil {
+			t.Fatalf("SaveSnapshotAccounts() = %v", err)
+		}
+		if err := repo.SaveSnapshotDelegations(ctx(), snapshot.ID, []models.SnapshotDelegation{{Delegator: "b", Delegate: "a"}}); err != nil {
+			t.Fatalf("SaveSnapshotDelegations() = %v", err)
+		}
+		if err := repo.SaveSnapshotExclusions(ctx(), snapshot.ID, []models.SnapshotExclusion{{Address: "c", Reason: "treasury"}}); err != nil {
+			t.Fatalf("SaveSnapshotExclusions() = %v", err)
+		}
+		if status != models.SnapshotRunning {
+			snapshot.Status = status
+			if err := repo.FinishSnapshot(ctx(), &snapshot); err != nil {
+				t.Fatalf("FinishSnapshot() = %v", err)
+			}
+		}
+		return snapshot
+	}
+
+	now := time.Now()
+	cutoff := now.Add(time.Hour)
+	completed := create(models.SnapshotCompleted, nil, now)
+	failed := create(models.SnapshotFailed, nil, now)
+	// Left running by a run that never ended
+	stale := create(models.SnapshotRunning, nil, base)
+	// Started after the cutoff
+	running := create(models.SnapshotRunning, nil, cutoff.Add(time.Hour))
+	derived := create(models.SnapshotRunning, &completed.ID, cutoff.Add(time.Hour))
+
+	// Nothing finished a day ago, only the stale snapshot started before
+	deleted, err := repo.DeleteSnapshots(ctx(), now.Add(-24*time.Hour))
+	if err != nil || deleted != 1 {
+		t.Errorf("DeleteSnapshots() of a day ago = %d, %v, want the stale running snapshot", deleted, err)
+	}
+
+	if deleted, err = repo.DeleteSnapshots(ctx(), cutoff); err != nil {
+		t.Fatalf("DeleteSnapshots() = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteSnapshots() = %d, want the completed and the failed snapshots", deleted)
+	}
+	for _, id := range []int64{completed.ID, failed.ID, stale.ID} {
+		if _, err := repo.GetSnapshot(ctx(), id); !errors.Is(err, dal.ErrNotFound) {
+			t.Errorf("GetSnapshot(%d) after its deletion = %v, want %v", id, err, dal.ErrNotFound)
+		}
+		accounts, _ := repo.ListSnapshotAccounts(ctx(), id)
+		delegations, _ := repo.ListSnapshotDelegations(ctx(), id)
+		exclusions, _ := repo.ListSnapshotExclusions(ctx(), id)
+		if len(accounts)+len(delegations)+len(exclusions) != 0 {
+			t.Errorf("snapshot %d kept %d accounts, %d delegations and %d exclusions", id, len(accounts), len(delegations), len(exclusions))
+		}
+	}
+
+	if got, err := repo.GetSnapshot(ctx(), running.ID); err != nil || got.Status != models.SnapshotRunning {
+		t.Errorf("GetSnapshot() of the running snapshot = %+v, %v", got, err)
+	}
+	got, err := repo.GetSnapshot(ctx(), derived.ID)
+	if err != nil {
+		t.Fatalf("GetSnapshot() of the derived snapshot = %v", err)
+	}
+	if got.ParentID != nil {
+		t.Errorf("derived snapshot parent = %d, want none once its parent is deleted", *got.ParentID)
+	}
+	if accounts, err := repo.ListSnapshotAccounts(ctx(), derived.ID); err != nil || len(accounts) != 1 {
+		t.Errorf("ListSnapshotAccounts() of the derived snapshot = %v, %v, want its account", accounts, err)
+	}
+}
@@ -0,0 +1,72 @@
+package dal
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SQLSTATE codes of the transactions that may succeed when run again
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	// Class 08 is a connection exception
+	connectionException = "08"
+
+	retryInitialInterval = 100 * time.Millisecond
+	retryMaxInterval     = 2 * time.Second
+)
+
+// retryable - Whether an error is transient: a serialization failure, a deadlock or a lost connection
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected ||
+			strings.HasPrefix(pgErr.Code, connectionException)
+	}
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		pgconn.SafeToRetry(err)
+}
+
+// retry - Run an idempotent operation again, with an exponential backoff, while it fails on a
+// transient error. Operations that are not idempotent, like plain inserts, must not be retried.
+func (db *Db) retry(ctx context.Context, operation string, f func() error) error {
+	retries := 0
+	if db.Cfg != nil {
+		retries = db.Cfg.Database.Retries
+	}
+
+	// WithMaxRetries takes zero as no limit
+	var policy backoff.BackOff = &backoff.StopBackOff{}
+	if retries > 0 {
+		exponential := backoff.NewExponentialBackOff()
+		exponential.InitialInterval = retryInitialInterval
+		exponential.MaxInterval = retryMaxInterval
+		exponential.MaxElapsedTime = 0
+		policy = backoff.WithMaxRetries(exponential, uint64(retries))
+	}
+
+	return backoff.RetryNotify(func() error {
+		err := f()
+		if err != nil && !retryable(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithContext(policy, ctx), func(err error, next time.Duration) {
+		dbRetries.WithLabelValues(operation).Inc()
+		trackingNumber, _ := ctx.Value("tracking_number").(string)
+		db.Log.Warnf("%s | Retrying %s in %s after a transient error: %v", trackingNumber, operation, next, err)
+	})
+}
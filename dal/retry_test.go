@@ -0,0 +1,128 @@
+package dal
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+	"gorm.io/gorm"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"wrapped serialization failure", errors.Wrap(&pgconn.PgError{Code: "40001"}, "Failed saving"), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"bad connection", driver.ErrBadConn, true},
+		{"unexpected eof", fmt.Errorf("reading: %w", io.ErrUnexpectedEOF), true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{"broken pipe", os.NewSyscallError("write", syscall.EPIPE), true},
+		{"not found", ErrNotFound, false},
+		{"record not found", gorm.ErrRecordNotFound, false},
+		{"cancelled", context.Canceled, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// newRetryingDb - A Db whose only setting is the number of retries
+func newRetryingDb(retries int) *Db {
+	cfg := models.DefaultConfig()
+	cfg.Database.Retries = retries
+	return &Db{Cfg: &cfg, Log: &logger.NoopLogger{}}
+}
+
+func TestRetry(t *testing.T) {
+	transient := &pgconn.PgError{Code: "40001"}
+	permanent := &pgconn.PgError{Code: "23505"}
+
+	tests := []struct {
+		name     string
+		retries  int
+		failures []error
+		want     error
+		calls    int
+	}{
+		{"success", 3, nil, nil, 1},
+		{"transient then success", 3, []error{transient, driver.ErrBadConn}, nil, 3},
+		{"transient every time", 2, []error{transient, transient, transient, transient}, transient, 3},
+		{"permanent", 3, []error{permanent}, permanent, 1},
+		{"no retries", 0, []error{transient}, transient, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newRetryingDb(tt.retries)
+			retried := testutil.ToFloat64(dbRetries.WithLabelValues(t.Name()))
+
+			calls := 0
+			err := db.retry(context.Background(), t.Name(), func() error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+			if err != tt.want {
+				t.Errorf("retry() = %v, want %v", err, tt.want)
+			}
+			if calls != tt.calls {
+				t.Errorf("calls = %d, want %d", calls, tt.calls)
+			}
+			if got := testutil.ToFloat64(dbRetries.WithLabelValues(t.Name())) - retried; got != float64(tt.calls-1) {
+				t.Errorf("db_retries_total grew by %v, want %d", got, tt.calls-1)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWithItsContext(t *testing.T) {
+	db := newRetryingDb(10)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := db.retry(ctx, "cancelled", func() error {
+		calls++
+		cancel()
+		return driver.ErrBadConn
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("retry() = %v after %d calls, want the error of the only call", err, calls)
+	}
+}
+
+func TestRetryWithoutConfig(t *testing.T) {
+	db := &Db{Log: &logger.NoopLogger{}}
+
+	calls := 0
+	if err := db.retry(context.Background(), "unconfigured", func() error {
+		calls++
+		return driver.ErrBadConn
+	}); err != driver.ErrBadConn || calls != 1 {
+		t.Errorf("retry() = %v after %d calls, want no retry", err, calls)
+	}
+}
@@ -0,0 +1,175 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// CreateSnapshot - Record the start of a run
+func (db *Db) CreateSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	defer observeWrite("create_snapshot")()
+
+	if err := db.Client.WithContext(ctx).Create(snapshot).Error; err != nil {
+		return errors.Wrap(err, "failed creating snapshot")
+	}
+
+	return nil
+}
+
+// FinishSnapshot - Record the outcome of a run
+func (db *Db) FinishSnapshot(ctx context.Context, snapshot *models.Snapshot) error {
+	defer observeWrite("finish_snapshot")()
+
+	now := time.Now()
+	snapshot.FinishedAt = &now
+
+	if err := db.retry(ctx, "finish_snapshot", func() error {
+		return db.Client.WithContext(ctx).Model(snapshot).Updates(map[string]interface{}{
+			"status":           snapshot.Status,
+			"chain_total_ndau": snapshot.ChainTotalNdau,
+			"finished_at":      snapshot.FinishedAt,
+		}).Error
+	}); err != nil {
+		return errors.Wrapf(err, "failed finishing snapshot '%d'", snapshot.ID)
+	}
+
+	return nil
+}
+
+// SaveSnapshotAccounts - Store the accounts of a snapshot
+func (db *Db) SaveSnapshotAccounts(ctx context.Context, snapshotId int64, accounts []models.SnapshotAccount) error {
+	defer observeWrite("save_snapshot_accounts")()
+
+	if len(accounts) == 0 {
+		return nil
+	}
+	for i := range accounts {
+		accounts[i].SnapshotID = snapshotId
+	}
+
+	if err := db.Client.WithContext(ctx).CreateInBatches(accounts, 1000).Error; err != nil {
+		return errors.Wrapf(err, "failed saving accounts of snapshot '%d'", snapshotId)
+	}
+
+	return nil
+}
+
+// GetSnapshot - Read a snapshot by id
+func (db *Db) GetSnapshot(ctx context.Context, snapshotId int64) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	if err := db.retry(ctx, "get_snapshot", func() error {
+		return db.Client.WithContext(ctx).Where("id = ?", snapshotId).Take(&snapshot).Error
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed reading snapshot '%d'", snapshotId)
+	}
+
+	return &snapshot, nil
+}
+
+// ListSnapshotAccounts - Read the accounts of a snapshot
+func (db *Db) ListSnapshotAccounts(ctx context.Context, snapshotId int64) ([]models.SnapshotAccount, error) {
+	accounts := []models.SnapshotAccount{}
+	if err := db.retry(ctx, "list_snapshot_accounts", func() error {
+		return db.Client.WithContext(ctx).Where("snapshot_id = ?", snapshotId).Order("address asc").Find(&accounts).Error
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed reading accounts of snapshot '%d'", snapshotId)
+	}
+
+	return accounts, nil
+}
+
+// SaveSnapshotDelegations - Store the delegations applied by a snapshot
+func (db *Db) SaveSnapshotDelegations(ctx context.Context, snapshotId int64, delegations []models.SnapshotDelegation) error {
+	defer observeWrite("save_snapshot_delegations")()
+
+	if len(delegations) == 0 {
+		return nil
+	}
+	for i := range delegations {
+		delegations[i].SnapshotID = snapshotId
+	}
+
+	if err := db.Client.WithContext(ctx).CreateInBatches(delegations, 1000).Error; err != nil {
+		return errors.Wrapf(err, "failed saving delegations of snapshot '%d'", snapshotId)
+	}
+
+	return nil
+}
+
+// ListSnapshotDelegations - Read the delegations applied by a snapshot
+func (db *Db) ListSnapshotDelegations(ctx context.Context, snapshotId int64) ([]models.SnapshotDelegation, error) {
+	delegations := []models.SnapshotDelegation{}
+	if err := db.retry(ctx, "list_snapshot_delegations", func() error {
+		return db.Client.WithContext(ctx).Where("snapshot_id = ?", snapshotId).Order("delegator asc").Find(&delegations).Error
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed reading delegations of snapshot '%d'", snapshotId)
+	}
+
+	return delegations, nil
+}
+
+// SaveSnapshotExclusions - Store the exclusions applied by a snapshot
+func (db *Db) SaveSnapshotExclusions(ctx context.Context, snapshotId int64, exclusions []models.SnapshotExclusion) error {
+	defer observeWrite("save_snapshot_exclusions")()
+
+	if len(exclusions) == 0 {
+		return nil
+	}
+	for i := range exclusions {
+		exclusions[i].SnapshotID = snapshotId
+	}
+
+	if err := db.Client.WithContext(ctx).CreateInBatches(exclusions, 1000).Error; err != nil {
+		return errors.Wrapf(err, "failed saving exclusions of snapshot '%d'", snapshotId)
+	}
+
+	return nil
+}
+
+// ListSnapshotExclusions - Read the exclusions applied by a snapshot
+func (db *Db) ListSnapshotExclusions(ctx context.Context, snapshotId int64) ([]models.SnapshotExclusion, error) {
+	exclusions := []models.SnapshotExclusion{}
+	if err := db.retry(ctx, "list_snapshot_exclusions", func() error {
+		return db.Client.WithContext(ctx).Where("snapshot_id = ?", snapshotId).Order("address asc").Find(&exclusions).Error
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed reading exclusions of snapshot '%d'", snapshotId)
+	}
+
+	return exclusions, nil
+}
+
+// DeleteSnapshots - Delete the snapshots finished before a date with their accounts, delegations and
+// exclusions. A snapshot still running that started before it was left by a run that never ended, e.g. a
+// killed pod, and is deleted too. The snapshots derived from a deleted one lose their parent.
+func (db *Db) DeleteSnapshots(ctx context.Context, before time.Time) (int64, error) {
+	defer observeWrite("delete_snapshots")()
+
+	var deleted int64
+	err := db.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expired := func() *gorm.DB {
+			return tx.Where("status <> ? AND finished_at < ?", models.SnapshotRunning, before).
+				Or("status = ? AND started_at < ?", models.SnapshotRunning, before)
+		}
+		if err := tx.Model(&models.Snapshot{}).Where("parent_id IN (?)", expired().Model(&models.Snapshot{}).Select("id")).
+			Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+
+		result := expired().Delete(&models.Snapshot{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed deleting expired snapshots")
+	}
+
+	return deleted, nil
+}
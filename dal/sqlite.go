@@ -0,0 +1,58 @@
+package dal
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"github.com/pkg/errors"
+
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+)
+
+const sqliteDialect = "sqlite"
+
+//go:embed sqlite/schema.sql
+var sqliteSchema string
+
+// NewSqlite - A Db on a SQLite file, or in memory with ":memory:", for local development and tests.
+// SQLite allows a single writer, the pool holds a single connection.
+func NewSqlite(path string, cfg *models.Config, log logger.Logger) (*Db, error) {
+	if path == "" {
+		return nil, errors.New("the sqlite database file is required")
+	}
+	log.Infof("Opening the sqlite database %s", path)
+
+	db, err := openDb(sqlite.Open(sqliteDSN(path)), cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.Client.DB()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed getting the database handle")
+	}
+	// Each connection to :memory: is a database of its own
+	sqlDB.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// sqliteDSN - The file URI of a database with foreign keys enforced and a wait on locks
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return "file:" + path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// migrateSqlite - Create the tables that do not exist yet, SQLite databases are not versioned
+func (db *Db) migrateSqlite(ctx context.Context) error {
+	if err := db.Client.WithContext(ctx).Exec(sqliteSchema).Error; err != nil {
+		return errors.Wrap(err, "failed creating the sqlite schema")
+	}
+
+	return nil
+}
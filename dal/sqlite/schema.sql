@@ -0,0 +1,100 @@
+-- The schema of dal/migrations for SQLite, used for local development and tests.
+-- Timestamps are stored as text, which sorts in time order as long as they are in UTC.
+
+CREATE TABLE IF NOT EXISTS accounts (
+    address            text PRIMARY KEY,
+    currency_seat_date datetime NOT NULL DEFAULT '0001-01-01',
+    votes              real NOT NULL DEFAULT 0,
+    effective_votes    real NOT NULL DEFAULT 0,
+    eligible           boolean NOT NULL DEFAULT true,
+    eligibility_reason text NOT NULL DEFAULT 'regular'
+);
+
+CREATE INDEX IF NOT EXISTS accounts_currency_seat_date_idx ON accounts (currency_seat_date);
+CREATE INDEX IF NOT EXISTS accounts_votes_idx ON accounts (votes);
+
+CREATE TABLE IF NOT EXISTS proposals (
+    proposal_id           integer PRIMARY KEY AUTOINCREMENT,
+    is_approved           boolean,
+    closing_date          datetime NOT NULL,
+    concluded             boolean,
+    concluded_total_power real
+);
+
+CREATE TABLE IF NOT EXISTS votes (
+    id              integer PRIMARY KEY AUTOINCREMENT,
+    proposal_id     integer NOT NULL,
+    user_address    text NOT NULL,
+    choice          text NOT NULL,
+    cast_at         datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    concluded_votes real
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS votes_proposal_id_user_address_idx ON votes (proposal_id, user_address);
+
+CREATE TABLE IF NOT EXISTS delegations (
+    delegator  text PRIMARY KEY,
+    delegate   text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (delegator <> delegate)
+);
+
+CREATE INDEX IF NOT EXISTS delegations_delegate_idx ON delegations (delegate);
+
+CREATE TABLE IF NOT EXISTS exclusions (
+    address    text PRIMARY KEY,
+    reason     text NOT NULL,
+    created_by text NOT NULL,
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS exclusion_audits (
+    id      integer PRIMARY KEY AUTOINCREMENT,
+    address text NOT NULL,
+    action  text NOT NULL,
+    reason  text NOT NULL,
+    actor   text NOT NULL,
+    at      datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS exclusion_audits_address_idx ON exclusion_audits (address);
+
+CREATE TABLE IF NOT EXISTS snapshots (
+    id               integer PRIMARY KEY AUTOINCREMENT,
+    tracking_number  text NOT NULL,
+    parent_id        integer REFERENCES snapshots (id),
+    policy           text NOT NULL,
+    status           text NOT NULL,
+    network          text NOT NULL DEFAULT '',
+    chain_total_ndau integer NOT NULL DEFAULT 0,
+    started_at       datetime NOT NULL,
+    finished_at      datetime
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_accounts (
+    snapshot_id        integer NOT NULL REFERENCES snapshots (id) ON DELETE CASCADE,
+    address            text NOT NULL,
+    balance            integer NOT NULL,
+    currency_seat_date datetime NOT NULL,
+    class              text NOT NULL,
+    eligible           boolean NOT NULL,
+    votes              real NOT NULL DEFAULT 0,
+    effective_votes    real NOT NULL DEFAULT 0,
+    PRIMARY KEY (snapshot_id, address)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_delegations (
+    snapshot_id integer NOT NULL REFERENCES snapshots (id) ON DELETE CASCADE,
+    delegator   text NOT NULL,
+    delegate    text NOT NULL,
+    PRIMARY KEY (snapshot_id, delegator)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_exclusions (
+    snapshot_id integer NOT NULL REFERENCES snapshots (id) ON DELETE CASCADE,
+    address     text NOT NULL,
+    reason      text NOT NULL,
+    PRIMARY KEY (snapshot_id, address)
+);
+
+CREATE INDEX IF NOT EXISTS snapshots_finished_at_idx ON snapshots (finished_at);
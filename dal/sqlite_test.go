@@ -0,0 +1,41 @@
+package dal_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/dal/repotest"
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+)
+
+// openSqlite - A migrated SQLite repository on the given file
+func openSqlite(t *testing.T, path string) repotest.Repo {
+	t.Helper()
+
+	cfg := models.DefaultConfig()
+	cfg.Database.LogLevel = "silent"
+	repo, err := dal.NewSqlite(path, &cfg, &logger.NoopLogger{})
+	if err != nil {
+		t.Fatalf("NewSqlite() = %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if err := repo.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+	return repo
+}
+
+func TestSqliteConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repo {
+		return openSqlite(t, ":memory:")
+	})
+}
+
+func TestSqliteFileConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repo {
+		return openSqlite(t, filepath.Join(t.TempDir(), "voting.db"))
+	})
+}
@@ -0,0 +1,97 @@
+package dal
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "otel:span"
+
+// tracingPlugin - A GORM plugin starting a span for each query, as a child of the span of its context
+type tracingPlugin struct {
+	tracer trace.Tracer
+}
+
+func newTracingPlugin() *tracingPlugin {
+	return &tracingPlugin{tracer: otel.Tracer("github.com/ndau/dao-voting-setup/dal")}
+}
+
+// Name -
+func (p *tracingPlugin) Name() string {
+	return "otel-tracing"
+}
+
+// Initialize - Register the callbacks around every kind of query
+func (p *tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		op     string
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, hook := range hooks {
+		if err := hook.before("otel:before_"+hook.op, p.before(hook.op)); err != nil {
+			return err
+		}
+		if err := hook.after("otel:after_"+hook.op, p.after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *tracingPlugin) before(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx, span := p.tracer.Start(tx.Statement.Context, "gorm."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(dbSystem(tx.Dialector.Name())),
+		)
+		if trackingNumber, ok := ctx.Value("tracking_number").(string); ok {
+			span.SetAttributes(attribute.String("tracking_number", trackingNumber))
+		}
+		tx.Statement.Context = ctx
+		tx.InstanceSet(spanKey, span)
+	}
+}
+
+func (p *tracingPlugin) after(tx *gorm.DB) {
+	val, ok := tx.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := val.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBSQLTableKey.String(tx.Statement.Table),
+		semconv.DBStatementKey.String(tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	if err := tx.Error; err != nil && err != gorm.ErrRecordNotFound {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// dbSystem - The database system attribute of a GORM dialect
+func dbSystem(dialect string) attribute.KeyValue {
+	if dialect == sqliteDialect {
+		return semconv.DBSystemSqlite
+	}
+	return semconv.DBSystemPostgreSQL
+}
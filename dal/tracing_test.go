@@ -0,0 +1,73 @@
+package dal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// attributes - The attributes of a span by key
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracingPlugin(t *testing.T) {
+	cfg := models.DefaultConfig()
+	db, err := NewSqlite(":memory:", &cfg, &logger.NoopLogger{})
+	if err != nil {
+		t.Fatalf("NewSqlite() = %v", err)
+	}
+	defer db.Close()
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	if err := db.Client.Use(&tracingPlugin{tracer: provider.Tracer("test")}); err != nil {
+		t.Fatalf("Use() = %v", err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.WithValue(context.Background(), "tracking_number", "traced"), "run")
+	if _, err := db.GetProposal(ctx, 42); err != ErrNotFound {
+		t.Fatalf("GetProposal() = %v, want %v", err, ErrNotFound)
+	}
+	parent.End()
+
+	var query sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "gorm.query" {
+			query = span
+		}
+	}
+	if query == nil {
+		t.Fatalf("spans = %v, want a gorm.query span", recorder.Ended())
+	}
+	if query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("gorm.query parent = %s, want the span of its context", query.Parent().SpanID())
+	}
+	attrs := attributes(query)
+	if attrs[semconv.DBSystemKey].AsString() != "sqlite" {
+		t.Errorf("db.system = %q, want sqlite", attrs[semconv.DBSystemKey].AsString())
+	}
+	if attrs[semconv.DBSQLTableKey].AsString() != "proposals" || attrs[semconv.DBStatementKey].AsString() == "" {
+		t.Errorf("attributes = %v, want the table and the statement", attrs)
+	}
+	if attrs["tracking_number"].AsString() != "traced" {
+		t.Errorf("tracking_number = %q, want traced", attrs["tracking_number"].AsString())
+	}
+	// A missing row is not an error of the query
+	if query.Status().Code.String() == "Error" {
+		t.Errorf("gorm.query status = %v, want no error for a missing row", query.Status())
+	}
+}
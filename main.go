@@ -3,68 +3,134 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/cenkalti/backoff"
 	config "github.com/ndau/dao-voting-setup/configuration"
 	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
 	"github.com/ndau/dao-voting-setup/serving"
 	configure "github.com/ndau/go-config"
 	logger "github.com/ndau/go-logger"
 )
 
+const usage = `Usage: project [command] [flags]
+
+Commands:
+  serve                               Listen for PingSource events and serve the APIs (default)
+  run-once --network --node-api       Process one event and exit with its status
+  migrate                             Apply the schema migrations and exit
+  report --snapshot                   Print the allocation of a stored snapshot
+  recompute --snapshot [--policy]     Rerun the allocation of a stored snapshot without reading the chain
+`
+
+// command - A subcommand, it returns the exit code of the process
+type command func(ctx context.Context, args []string, store *config.Store, repo dal.Repo, log logger.Logger) int
+
+var commands = map[string]command{
+	"serve":     serve,
+	"run-once":  runOnce,
+	"migrate":   migrate,
+	"report":    report,
+	"recompute": recompute,
+}
+
 // main this is the main knative function.
 // if we panic here upon upgrade knative will not upgrade the pod and will use that last successful version of the container
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	// `serve` stays the default so that the existing deployments keep working
+	name := "serve"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n\n%s", name, usage)
+		return 2
+	}
+
 	// Load logger and configurator
 	log, err := logger.New("main", "main")
 	if err != nil {
 		fmt.Println("failed to create logger ", err)
-		return
+		return 1
 	}
 
 	log.Infof("Initializing config...")
-	cfg, er := configure.New()
+	cfg, err := configure.New()
 	if err != nil {
-		log.Error(er)
-		return
+		log.Error(err)
+		return 1
 	}
 
+	// SIGTERM is how Kubernetes and Knative stop the pod, the run in progress is cancelled
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
 	log.Infof("Loading config...")
-	ctx := context.Background()
 
-	cf, e := config.LoadConfig(ctx, cfg, log)
-	if e != nil {
-		log.Error(e)
-		return
-		//panic(e)
+	cf, err := config.LoadConfig(ctx, cfg, log)
+	if err != nil {
+		log.Error(err)
+		return 1
 	}
+	log.Infof("Effective configuration: %v", cf)
+	log.SetLevel(cf.LogLevel)
 
-	var repo dal.Repo
-	err = backoff.Retry(func() error {
-		repo, err = dal.NewDb(cf, log)
-		if err != nil {
-			log.Errorf("Failed to initialize db client: %v", err)
-		}
-
-		return err
-	}, backoff.NewConstantBackOff(4*time.Second))
+	// Runtime settings follow the configuration file, see configuration.Watch for the others
+	store := config.NewStore(cf)
+	store.OnChange(func(cf *models.Config) {
+		log.SetLevel(cf.LogLevel)
+	})
+	config.Watch(ctx, cfg, store, log)
 
+	shutdownTracing, err := serving.InitTracing(ctx, cf.Tracing, log)
 	if err != nil {
-		return
+		log.Errorf("Failed to initialize tracing: %v", err)
+		return 1
 	}
-	defer repo.Close()
+	defer func() {
+		// Flush the pending spans even though the context is cancelled
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
 
-	kn, err := serving.NewKnClient(cf, log)
-	if err != nil {
-		log.Error("Failed to initialize knative client: %v", err)
-		return
-		//panic(erKn)
+	// The server waits for the database up to database.startup_timeout_seconds, one-off commands
+	// give up after a few attempts
+	exponential := backoff.NewExponentialBackOff()
+	exponential.InitialInterval = time.Second
+	exponential.MaxInterval = 30 * time.Second
+	exponential.MaxElapsedTime = time.Duration(cf.Database.StartupTimeoutSeconds) * time.Second
+	var policy backoff.BackOff = exponential
+	if name != "serve" {
+		policy = backoff.WithMaxRetries(policy, 5)
 	}
+	policy = backoff.WithContext(policy, ctx)
 
-	kn.Run(ctx, repo, cf)
+	start := time.Now()
+	var repo dal.Repo
+	err = backoff.RetryNotify(func() error {
+		repo, err = dal.Open(cf, log)
+		return err
+	}, policy, func(err error, next time.Duration) {
+		log.Errorf("Failed to initialize db client, retrying in %s: %v", next.Round(time.Millisecond), err)
+	})
+	if err != nil {
+		log.Errorf("Giving up connecting to the database after %s: %v", time.Since(start).Round(time.Second), err)
+		return 1
+	}
+	// Closing twice is harmless, serve closes it once the server is shut down
+	defer repo.Close()
 
-	log.Info("Waiting on context Done channel...")
-	<-ctx.Done()
-	log.Info("quit...")
+	return cmd(ctx, args, store, repo, log)
 }
@@ -4,14 +4,80 @@ import (
 	"time"
 )
 
+const (
+	// SortByVotes - Order seated accounts by voting power
+	SortByVotes = "votes"
+	// SortBySeatDate - Order seated accounts by currency seat date
+	SortBySeatDate = "seat_date"
+
+	// DefaultAccountPage - The number of accounts of a page when no limit is given
+	DefaultAccountPage = 100
+	// MaxAccountPage - The largest page of accounts
+	MaxAccountPage = 1000
+)
+
+// SeatedSince - Accounts unseated by the job keep a zero currency seat date, seated ones are newer than the chain genesis
+var SeatedSince = time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 // VotingSetup -
 type VotingSetup struct {
 	Address          string
 	CurrencySeatDate time.Time
 	Votes            float64
+	// EffectiveVotes is the voting power after resolving delegations
+	EffectiveVotes float64
+	// Eligible is false for accounts excluded from the vote, EligibilityReason is their account class
+	Eligible          bool
+	EligibilityReason string
 }
 
 // TableName - Return table name
 func (t VotingSetup) TableName() string {
 	return "accounts"
 }
+
+// Seated - Whether the account holds a currency seat and takes part in the vote
+func (t VotingSetup) Seated() bool {
+	return t.Eligible && !t.CurrencySeatDate.Before(SeatedSince)
+}
+
+// AccountQuery - Pagination and ordering of the seated account list
+type AccountQuery struct {
+	// Limit is the maximum number of accounts returned
+	Limit int
+	// Offset is the number of accounts skipped
+	Offset int
+	// SortBy is either SortByVotes or SortBySeatDate
+	SortBy string
+	// Desc reverses the order
+	Desc bool
+}
+
+// PageLimit - The limit applied to the query, DefaultAccountPage when unset and at most MaxAccountPage
+func (t AccountQuery) PageLimit() int {
+	switch {
+	case t.Limit <= 0:
+		return DefaultAccountPage
+	case t.Limit > MaxAccountPage:
+		return MaxAccountPage
+	}
+	return t.Limit
+}
+
+// Stats - Aggregate figures over the accounts
+type Stats struct {
+	// SeatCount is the number of seated accounts
+	SeatCount int64
+	// TotalVotes is the voting power of all the accounts
+	TotalVotes float64
+	// TopHolders are the seated accounts of most voting power
+	TopHolders []VotingSetup
+}
+
+// AccountPage - A page of seated accounts
+type AccountPage struct {
+	Total    int64
+	Limit    int
+	Offset   int
+	Accounts []VotingSetup
+}
@@ -0,0 +1,21 @@
+package models
+
+import "testing"
+
+func TestPageLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{0, DefaultAccountPage},
+		{-1, DefaultAccountPage},
+		{1, 1},
+		{MaxAccountPage, MaxAccountPage},
+		{MaxAccountPage + 1, MaxAccountPage},
+	}
+	for _, tt := range tests {
+		if got := (AccountQuery{Limit: tt.limit}).PageLimit(); got != tt.want {
+			t.Errorf("PageLimit() of %d = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
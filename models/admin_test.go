@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAdminTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens map[string]string
+		want   string
+	}{
+		{"named operators", map[string]string{"alice": "alice-0123456789abcdef", "bob": "bob-0123456789abcdef"}, ""},
+		{"no operator", nil, ""},
+		{"a short token", map[string]string{"alice": "short"}, "the admin token of 'alice' must be at least 16 characters"},
+		{"a shared token", map[string]string{"alice": "shared-0123456789abcdef", "bob": "shared-0123456789abcdef"}, "operators 'alice' and 'bob' share an admin token"},
+		{"no name", map[string]string{" ": "blank-0123456789abcdef"}, "admin tokens need an operator name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ConnectionString = "host=localhost"
+			cfg.Admin.Tokens = tt.tokens
+
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminConfigRedactsTheTokens(t *testing.T) {
+	cfg := AdminConfig{Tokens: map[string]string{"alice": "alice-0123456789abcdef"}}
+
+	if s := cfg.String(); strings.Contains(s, "alice-0123456789abcdef") || !strings.Contains(s, "alice") {
+		t.Errorf("String() = %s, want the operator without its token", s)
+	}
+	if cfg.Tokens["alice"] != "alice-0123456789abcdef" {
+		t.Error("Redacted() changed the tokens of the configuration")
+	}
+}
@@ -2,7 +2,125 @@ package models
 
 // Config ...
 type Config struct {
+	// ConnectionString is a postgres:// URL or a libpq DSN, Database fills in or overrides its settings
 	ConnectionString string
+	Database         DatabaseConfig `mapstructure:"database"`
+	// AutoMigrate applies pending schema migrations at startup
+	AutoMigrate bool `mapstructure:"auto_migrate"`
+	// LogLevel of the service: debug, info, warn or error
+	LogLevel string `mapstructure:"log_level"`
+	// Port of the knative HTTP server
+	Port int `mapstructure:"port"`
+	// Network and NodeAPI are the defaults of the runs, and the node used outside of them, e.g. to verify votes.
+	// NodeAPI is the first of Nodes, the next ones are tried in order when it does not answer at the start of a run.
+	Network string   `mapstructure:"network"`
+	NodeAPI string   `mapstructure:"node_api"`
+	Nodes   []string `mapstructure:"nodes"`
+	// Run tunes how the chain is read
+	Run RunConfig `mapstructure:"run"`
+	// Features toggles optional parts of the service
+	Features FeatureToggles `mapstructure:"features"`
+	// Eligibility selects the account classes taking part in the vote
+	Eligibility EligibilityPolicy `mapstructure:"eligibility"`
+	// Exclusions are merged with the exclusions table at each run
+	Exclusions []Exclusion `mapstructure:"exclusions"`
+	// Admin secures the admin endpoints
+	Admin AdminConfig `mapstructure:"admin"`
+	// Health tunes the readiness checks
+	Health HealthConfig `mapstructure:"health"`
+	// Tracing exports OpenTelemetry spans, disabled by default
+	Tracing TracingConfig `mapstructure:"tracing"`
+	// DefaultPolicy names the allocation policy of the scheduled runs among Policies
+	DefaultPolicy string                      `mapstructure:"default_policy"`
+	Policies      map[string]AllocationPolicy `mapstructure:"policies"`
+}
+
+// DatabaseConfig - Separate database settings, an alternative to the connection string
+type DatabaseConfig struct {
+	// Driver is postgres, sqlite, whose file is Name, or memory. The last two are meant for local development
+	Driver   string `mapstructure:"driver"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	// PasswordFile is read when no password is set inline, e.g. a mounted Kubernetes secret
+	PasswordFile    string `mapstructure:"password_file"`
+	Name            string `mapstructure:"name"`
+	SSLMode         string `mapstructure:"sslmode"`
+	SSLRootCert     string `mapstructure:"sslrootcert"`
+	ApplicationName string `mapstructure:"application_name"`
+	// LogLevel of the SQL statements: silent, error, warn or info. At warn only slow queries are logged
+	LogLevel    string `mapstructure:"log_level"`
+	SlowQueryMs int    `mapstructure:"slow_query_ms"`
+	// Connection pool, zero keeps the driver default
+	MaxOpenConns           int `mapstructure:"max_open_conns"`
+	MaxIdleConns           int `mapstructure:"max_idle_conns"`
+	ConnMaxLifetimeSeconds int `mapstructure:"conn_max_lifetime_seconds"`
+	ConnMaxIdleTimeSeconds int `mapstructure:"conn_max_idle_time_seconds"`
+	// Retries of the idempotent operations failing on a transient error, zero disables them
+	Retries int `mapstructure:"retries"`
+	// StartupTimeoutSeconds bounds the time spent waiting for the database at startup, zero waits forever
+	StartupTimeoutSeconds int `mapstructure:"startup_timeout_seconds"`
+}
+
+// RunConfig - Defaults of the runs and how hard they hit the node
+type RunConfig struct {
+	// PageSize is the number of addresses of each /account/list page
+	PageSize      int    `mapstructure:"page_size"`
+	StartAfterKey string `mapstructure:"start_after_key"`
+	// BatchSize is the number of accounts of each /account/accounts request
+	BatchSize int `mapstructure:"batch_size"`
+	// BatchPauseMs gives the node a break between two batches
+	BatchPauseMs int `mapstructure:"batch_pause_ms"`
+	// RequestsPerSecond caps the requests sent to the node, zero is unlimited
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	// SnapshotRetentionDays deletes the snapshots finished longer ago after each run, zero keeps them all
+	SnapshotRetentionDays int `mapstructure:"snapshot_retention_days"`
+}
+
+// FeatureToggles - Optional parts of the service, all enabled by default
+type FeatureToggles struct {
+	// Delegations hands the voting power of delegators over to their delegates
+	Delegations bool `mapstructure:"delegations"`
+	// ConcludeProposals freezes the votes of closed proposals at the end of each run
+	ConcludeProposals bool `mapstructure:"conclude_proposals"`
+	// QueryAPI serves the accounts, proposals, votes and stats endpoints
+	QueryAPI bool `mapstructure:"query_api"`
+}
+
+// AdminConfig - Authentication of the admin endpoints. Requests are rejected unless they carry
+// the bearer token of an operator or, when served over TLS, a client certificate signed by ClientCAFile.
+type AdminConfig struct {
+	// Tokens names each operator after its bearer token, the name is recorded in the audit trail
+	Tokens map[string]string `mapstructure:"tokens"`
+	// When TLSCertFile and TLSKeyFile are set the admin endpoints move to their own TLS listener on Port
+	Port         int    `mapstructure:"port"`
+	TLSCertFile  string `mapstructure:"tls_cert_file"`
+	TLSKeyFile   string `mapstructure:"tls_key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+}
+
+// TLS - Whether the admin endpoints are served over TLS
+func (t AdminConfig) TLS() bool {
+	return t.TLSCertFile != "" && t.TLSKeyFile != ""
+}
+
+// HealthConfig - What /readyz checks besides the database
+type HealthConfig struct {
+	// CheckNode also requires the node API to answer
+	CheckNode      bool `mapstructure:"check_node"`
+	TimeoutSeconds int  `mapstructure:"timeout_seconds"`
+}
+
+// TracingConfig - Where the OpenTelemetry spans are exported through OTLP over HTTP
+type TracingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint is the host and port of the collector, e.g. otel-collector:4318
+	Endpoint string `mapstructure:"endpoint"`
+	Insecure bool   `mapstructure:"insecure"`
+	// SampleRatio is the share of the traces started here that are recorded
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	ServiceName string  `mapstructure:"service_name"`
 }
 
 // Cache
@@ -0,0 +1,56 @@
+package models
+
+const (
+	defaultPort          = 8080
+	defaultAdminPort     = 8443
+	defaultPageSize      = 100
+	defaultBatchSize     = 300
+	defaultBatchPauseMs  = 5000
+	defaultHealthTimeout = 2
+	defaultLogLevel      = "info"
+	defaultDBLogLevel    = "warn"
+	defaultDBDriver      = "postgres"
+	defaultSlowQueryMs   = 200
+	defaultDBRetries     = 3
+	defaultDBStartup     = 300
+	defaultServiceName   = "dao-voting-setup"
+)
+
+// DefaultConfig - The configuration before the configuration file and the secret are applied
+func DefaultConfig() Config {
+	return Config{
+		AutoMigrate: true,
+		LogLevel:    defaultLogLevel,
+		Port:        defaultPort,
+		Database: DatabaseConfig{
+			Driver:                defaultDBDriver,
+			LogLevel:              defaultDBLogLevel,
+			SlowQueryMs:           defaultSlowQueryMs,
+			Retries:               defaultDBRetries,
+			StartupTimeoutSeconds: defaultDBStartup,
+		},
+		Run: RunConfig{
+			PageSize:      defaultPageSize,
+			StartAfterKey: "-",
+			BatchSize:     defaultBatchSize,
+			BatchPauseMs:  defaultBatchPauseMs,
+		},
+		Features: FeatureToggles{
+			Delegations:       true,
+			ConcludeProposals: true,
+			QueryAPI:          true,
+		},
+		Eligibility: DefaultEligibilityPolicy(),
+		Admin: AdminConfig{
+			Port: defaultAdminPort,
+		},
+		Health: HealthConfig{
+			TimeoutSeconds: defaultHealthTimeout,
+		},
+		Tracing: TracingConfig{
+			SampleRatio: 1,
+			ServiceName: defaultServiceName,
+		},
+		DefaultPolicy: DefaultPolicyName,
+	}
+}
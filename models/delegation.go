@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+// MaxDelegationDepth - Longest delegation chain followed when resolving voting power
+const MaxDelegationDepth = 8
+
+// Delegation - An account handing its voting power to another one
+type Delegation struct {
+	Delegator string
+	Delegate  string
+	CreatedAt time.Time
+}
+
+// TableName - Return table name
+func (t Delegation) TableName() string {
+	return "delegations"
+}
+
+// Delegations - Delegate of each delegator
+type Delegations map[string]string
+
+// NewDelegations - Index a delegation list by delegator
+func NewDelegations(list []Delegation) Delegations {
+	d := Delegations{}
+	for _, delegation := range list {
+		d[delegation.Delegator] = delegation.Delegate
+	}
+	return d
+}
+
+// Resolve - Follow the chain of an account to the last delegate.
+// It fails on cycles and chains longer than maxDepth, the account then keeps its own power.
+func (d Delegations) Resolve(address string, maxDepth int) (string, bool) {
+	current := address
+	seen := map[string]struct{}{address: {}}
+	for depth := 0; ; depth++ {
+		next, ok := d[current]
+		if !ok {
+			return current, current != address
+		}
+		if depth == maxDepth {
+			return "", false
+		}
+		if _, cycle := seen[next]; cycle {
+			return "", false
+		}
+		seen[next] = struct{}{}
+		current = next
+	}
+}
+
+// ResolveVoter - The account whose vote carries the power of address on a proposal: the account itself
+// when it voted, else the last delegate of its chain when that one voted. This is the resolution of the
+// effective voting power, so a voter never carries more than its effective votes.
+// It fails when neither voted.
+func (d Delegations) ResolveVoter(address string, voted map[string]struct{}, maxDepth int) (string, bool) {
+	if _, ok := voted[address]; ok {
+		return address, true
+	}
+	delegate, ok := d.Resolve(address, maxDepth)
+	if !ok {
+		return "", false
+	}
+	if _, ok := voted[delegate]; !ok {
+		return "", false
+	}
+	return delegate, true
+}
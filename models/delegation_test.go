@@ -0,0 +1,70 @@
+package models
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	delegations := Delegations{
+		"a": "b", "b": "c", // a chain
+		"x": "y", "y": "x", // a cycle
+		"long0": "long1", "long1": "long2", "long2": "long3", "long3": "long4",
+	}
+
+	tests := []struct {
+		address  string
+		maxDepth int
+		want     string
+		ok       bool
+	}{
+		{"a", MaxDelegationDepth, "c", true},
+		{"b", MaxDelegationDepth, "c", true},
+		{"c", MaxDelegationDepth, "c", false},
+		{"nobody", MaxDelegationDepth, "nobody", false},
+		{"x", MaxDelegationDepth, "", false},
+		{"long0", 4, "long4", true},
+		{"long0", 3, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := delegations.Resolve(tt.address, tt.maxDepth)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Resolve(%s, %d) = %s, %t, want %s, %t", tt.address, tt.maxDepth, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolveVoter(t *testing.T) {
+	delegations := NewDelegations([]Delegation{
+		{Delegator: "a", Delegate: "b"},
+		{Delegator: "b", Delegate: "c"},
+		{Delegator: "x", Delegate: "y"},
+		{Delegator: "y", Delegate: "x"},
+	})
+
+	tests := []struct {
+		name    string
+		address string
+		voted   []string
+		want    string
+		ok      bool
+	}{
+		{"the end of the chain voted", "a", []string{"c"}, "c", true},
+		{"a voter keeps its own power", "a", []string{"a", "c"}, "a", true},
+		{"a delegate in the middle does not take the power", "a", []string{"b", "c"}, "c", true},
+		{"the end of the chain did not vote", "a", []string{"b"}, "", false},
+		{"an account without delegation that voted", "c", []string{"c"}, "c", true},
+		{"an account without delegation that did not vote", "c", nil, "", false},
+		{"a voter in a cycle", "x", []string{"x"}, "x", true},
+		{"a cycle", "x", []string{"y"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			voted := map[string]struct{}{}
+			for _, address := range tt.voted {
+				voted[address] = struct{}{}
+			}
+			got, ok := delegations.ResolveVoter(tt.address, voted, MaxDelegationDepth)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ResolveVoter(%s) = %s, %t, want %s, %t", tt.address, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
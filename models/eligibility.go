@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+)
+
+// Account classes, an account belongs to the first matching class in this order
+const (
+	AccountClassSystem   = "system"
+	AccountClassExchange = "exchange"
+	AccountClassNode     = "node"
+	AccountClassNotified = "notified"
+	AccountClassLocked   = "locked"
+	AccountClassRegular  = "regular"
+)
+
+// EligibilityPolicy - Which account classes take part in the vote.
+// Regular accounts are always eligible.
+type EligibilityPolicy struct {
+	// IncludeSystem keeps accounts listed in SystemAddresses
+	IncludeSystem bool `mapstructure:"include_system"`
+	// IncludeExchange keeps accounts listed in ExchangeAddresses
+	IncludeExchange bool `mapstructure:"include_exchange"`
+	// IncludeNode keeps accounts listed in NodeAddresses
+	IncludeNode bool `mapstructure:"include_node"`
+	// IncludeNotified keeps locked accounts that were notified for unlock
+	IncludeNotified bool `mapstructure:"include_notified"`
+	// IncludeLocked keeps locked accounts
+	IncludeLocked bool `mapstructure:"include_locked"`
+
+	SystemAddresses   []string `mapstructure:"system_addresses"`
+	ExchangeAddresses []string `mapstructure:"exchange_addresses"`
+	NodeAddresses     []string `mapstructure:"node_addresses"`
+}
+
+// DefaultEligibilityPolicy - Every account class is eligible
+func DefaultEligibilityPolicy() EligibilityPolicy {
+	return EligibilityPolicy{
+		IncludeSystem:   true,
+		IncludeExchange: true,
+		IncludeNode:     true,
+		IncludeNotified: true,
+		IncludeLocked:   true,
+	}
+}
+
+// Includes - Whether accounts of a class are eligible
+func (p EligibilityPolicy) Includes(class string) bool {
+	switch class {
+	case AccountClassSystem:
+		return p.IncludeSystem
+	case AccountClassExchange:
+		return p.IncludeExchange
+	case AccountClassNode:
+		return p.IncludeNode
+	case AccountClassNotified:
+		return p.IncludeNotified
+	case AccountClassLocked:
+		return p.IncludeLocked
+	default:
+		return true
+	}
+}
+
+// ChainAccount - State of an account read from the node, the voting power is computed from it
+type ChainAccount struct {
+	Address          string
+	Balance          int
+	CurrencySeatDate time.Time
+	// Class is the account class deciding its eligibility
+	Class    string
+	Eligible bool
+}
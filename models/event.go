@@ -6,17 +6,40 @@ type Event struct {
 	TrackingNumber string `json:"TrackingNumber,omitempty"`
 }
 
-// Data struct
+// Data struct - Every field is optional, the configuration provides the defaults
 type Data struct {
 	// Network
-	Network string `json:"Network"`
+	Network string `json:"Network,omitempty"`
 
 	// NodeAPI
-	NodeAPI string `json:"NodeAPI"`
+	NodeAPI string `json:"NodeAPI,omitempty"`
 
 	// Limit
-	Limit int `json:"Limit"`
+	Limit int `json:"Limit,omitempty"`
 
 	// StartAfterKey
-	StartAfterKey string `json:"StartAfterKey"`
+	StartAfterKey string `json:"StartAfterKey,omitempty"`
+
+	// Policy names the allocation policy, the configured default when empty
+	Policy string `json:"Policy,omitempty"`
+
+	// SnapshotID recomputes the allocation of a stored snapshot instead of reading the chain
+	SnapshotID int64 `json:"SnapshotID,omitempty"`
+}
+
+// WithDefaults - The request with its missing fields taken from the configuration
+func (t Data) WithDefaults(cfg *Config) Data {
+	if t.Network == "" {
+		t.Network = cfg.Network
+	}
+	if t.NodeAPI == "" {
+		t.NodeAPI = cfg.NodeAPI
+	}
+	if t.Limit == 0 {
+		t.Limit = cfg.Run.PageSize
+	}
+	if t.StartAfterKey == "" {
+		t.StartAfterKey = cfg.Run.StartAfterKey
+	}
+	return t
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// AccountClassExcluded - Account on the exclusion list, never eligible
+	AccountClassExcluded = "excluded"
+
+	// ExclusionAdded - Audit action of an address added to the exclusion list
+	ExclusionAdded = "add"
+	// ExclusionRemoved - Audit action of an address removed from the exclusion list
+	ExclusionRemoved = "remove"
+)
+
+// Exclusion - An address kept out of the vote
+type Exclusion struct {
+	Address   string    `mapstructure:"address"`
+	Reason    string    `mapstructure:"reason"`
+	CreatedBy string    `mapstructure:"-"`
+	CreatedAt time.Time `mapstructure:"-"`
+}
+
+// TableName - Return table name
+func (t Exclusion) TableName() string {
+	return "exclusions"
+}
+
+// ExclusionAudit - A change of the exclusion list
+type ExclusionAudit struct {
+	ID      int64
+	Address string
+	Action  string
+	Reason  string
+	Actor   string
+	At      time.Time
+}
+
+// TableName - Return table name
+func (t ExclusionAudit) TableName() string {
+	return "exclusion_audits"
+}
+
+// RunReport - Summary of a voting setup run
+type RunReport struct {
+	TrackingNumber string
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	// Accounts read from the node, and how many hold a seat or are excluded from the vote
+	Accounts         int
+	SeatedAccounts   int
+	ExcludedAccounts int
+	// ScannedNdau is the sum of the balances read, ChainTotalNdau the total reported by the node
+	ScannedNdau    int
+	ChainTotalNdau int
+	// ExcludedNdau is the supply held by ineligible accounts, also split by account class
+	ExcludedNdau        int
+	ExcludedNdauByClass map[string]int
+	ExcludedShare       float64
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// SchemaVersion - A schema migration applied to the database
+type SchemaVersion struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName - Return table name
+func (t SchemaVersion) TableName() string {
+	return "schema_version"
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+)
+
+// DefaultPolicyName - Policy used when none is configured or requested
+const DefaultPolicyName = "default"
+
+// AllocationPolicy - How the votes are shared between the accounts
+type AllocationPolicy struct {
+	// SeatVotes are shared equally by the currency seats
+	SeatVotes float64 `mapstructure:"seat_votes"`
+	// BalanceVotes are shared in proportion of the balances against the total ndau on chain
+	BalanceVotes float64 `mapstructure:"balance_votes"`
+	// SeniorityVotes are shared equally by the SenioritySeats oldest currency seats
+	SeniorityVotes float64 `mapstructure:"seniority_votes"`
+	SenioritySeats int     `mapstructure:"seniority_seats"`
+}
+
+// DefaultAllocationPolicy - 9,000,000 votes, one third for each of the seats, the balances and the three oldest seats
+func DefaultAllocationPolicy() AllocationPolicy {
+	return AllocationPolicy{
+		SeatVotes:      3000000,
+		BalanceVotes:   3000000,
+		SeniorityVotes: 3000000,
+		SenioritySeats: 3,
+	}
+}
+
+// Policy - Look a named allocation policy up, the default one is always available
+func (t *Config) Policy(name string) (AllocationPolicy, error) {
+	if name == "" {
+		name = t.DefaultPolicy
+	}
+	if policy, ok := t.Policies[name]; ok {
+		return policy, nil
+	}
+	if name == "" || name == DefaultPolicyName {
+		return DefaultAllocationPolicy(), nil
+	}
+	return AllocationPolicy{}, fmt.Errorf("unknown allocation policy '%s'", name)
+}
@@ -4,15 +4,38 @@ import (
 	"time"
 )
 
+const (
+	// ProposalStatusPending - Proposal not approved for voting yet
+	ProposalStatusPending = "pending"
+	// ProposalStatusOpen - Approved proposal that is still accepting votes
+	ProposalStatusOpen = "open"
+	// ProposalStatusConcluded - Approved proposal past its closing date
+	ProposalStatusConcluded = "concluded"
+)
+
 // Proposal -
 type Proposal struct {
 	ProposalID  int64
 	IsApproved  bool
 	ClosingDate time.Time
 	Concluded   bool
+	// ConcludedTotalPower is the total voting power when the votes were frozen, nil until then
+	ConcludedTotalPower *float64
 }
 
 // TableName - Return table name
 func (t Proposal) TableName() string {
 	return "proposals"
 }
+
+// Status - Return the proposal status at the given time
+func (t Proposal) Status(now time.Time) string {
+	switch {
+	case !t.IsApproved:
+		return ProposalStatusPending
+	case t.Concluded || !now.Before(t.ClosingDate):
+		return ProposalStatusConcluded
+	default:
+		return ProposalStatusOpen
+	}
+}
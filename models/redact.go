@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// redactedMask replaces the secrets of a logged configuration
+const redactedMask = "*****"
+
+var (
+	// The password of the user info of a URL, up to the last '@' before the path and the options, as
+	// dal parses it
+	urlPassword = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*://[^:/?@]*:)([^/?]*)(@[^/?@]*(?:[/?].*)?)$`)
+	// A password with a '/' or '?' that was not escaped does not parse, mask up to the last '@' anyway
+	urlUserInfo = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*://[^:/?@]*:)(.*)(@[^@]*)$`)
+	// The password of a DSN, quoted or not
+	dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+)
+
+// RedactConnectionString - Mask the password of a postgres:// URL or a DSN
+func RedactConnectionString(connectionString string) string {
+	for _, re := range []*regexp.Regexp{urlPassword, urlUserInfo} {
+		if re.MatchString(connectionString) {
+			return re.ReplaceAllString(connectionString, "${1}"+redactedMask+"${3}")
+		}
+	}
+	return dsnPassword.ReplaceAllString(connectionString, "${1}"+redactedMask)
+}
+
+// Redacted - A copy of the configuration whose secrets are masked, safe to log
+func (t Config) Redacted() Config {
+	t.ConnectionString = RedactConnectionString(t.ConnectionString)
+	t.Database = t.Database.Redacted()
+	t.Admin = t.Admin.Redacted()
+	return t
+}
+
+// String - Configurations are always logged redacted
+func (t Config) String() string {
+	type config Config
+	return fmt.Sprintf("%+v", config(t.Redacted()))
+}
+
+// GoString -
+func (t Config) GoString() string {
+	return t.String()
+}
+
+// Redacted - A copy of the database settings without the password
+func (t DatabaseConfig) Redacted() DatabaseConfig {
+	if t.Password != "" {
+		t.Password = redactedMask
+	}
+	return t
+}
+
+// String -
+func (t DatabaseConfig) String() string {
+	type database DatabaseConfig
+	return fmt.Sprintf("%+v", database(t.Redacted()))
+}
+
+// Redacted - A copy of the admin settings without the tokens
+func (t AdminConfig) Redacted() AdminConfig {
+	if t.Tokens != nil {
+		tokens := make(map[string]string, len(t.Tokens))
+		for name := range t.Tokens {
+			tokens[name] = redactedMask
+		}
+		t.Tokens = tokens
+	}
+	return t
+}
+
+// String -
+func (t AdminConfig) String() string {
+	type admin AdminConfig
+	return fmt.Sprintf("%+v", admin(t.Redacted()))
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRedactConnectionString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"url", "postgres://voting:secret@db:5432/voting", "postgres://voting:*****@db:5432/voting"},
+		{"url without password", "postgres://voting@db/voting", "postgres://voting@db/voting"},
+		{"url without user info", "postgres://db/voting?sslmode=require", "postgres://db/voting?sslmode=require"},
+		{"@ and : in the password", "postgres://voting:p@ss:w@db/voting", "postgres://voting:*****@db/voting"},
+		{"@ in an option", "postgres://voting:secret@db/voting?application_name=dao@ndau", "postgres://voting:*****@db/voting?application_name=dao@ndau"},
+		{"@ in an option without a database", "postgres://voting:secret@db?application_name=dao@ndau", "postgres://voting:*****@db?application_name=dao@ndau"},
+		{"escaped password", "postgresql://voting:p%2Fw%3F@db/voting", "postgresql://voting:*****@db/voting"},
+		{"/ in an unescaped password", "postgres://voting:se/cret@db/voting", "postgres://voting:*****@db/voting"},
+		{"dsn", "host=db user=voting password=secret dbname=voting", "host=db user=voting password=***** dbname=voting"},
+		{"quoted dsn", `host=db password = 'it\'s a secret' dbname=voting`, "host=db password = ***** dbname=voting"},
+		{"dsn without password", "host=db dbname=voting", "host=db dbname=voting"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactConnectionString(tt.in); got != tt.want {
+				t.Errorf("RedactConnectionString(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigIsLoggedRedacted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConnectionString = "postgres://voting:url-secret@db/voting"
+	cfg.Database.Password = "field-secret"
+	cfg.Admin.Tokens = map[string]string{"alice": "alice-0123456789abcdef"}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(format, cfg)
+		for _, secret := range []string{"url-secret", "field-secret", "alice-0123456789abcdef"} {
+			if strings.Contains(s, secret) {
+				t.Errorf("Sprintf(%s) leaks %s: %s", format, secret, s)
+			}
+		}
+		if !strings.Contains(s, "db/voting") {
+			t.Errorf("Sprintf(%s) = %s, want the rest of the connection string", format, s)
+		}
+	}
+	// Logging the database section alone is redacted too
+	if s := fmt.Sprintf("%v", cfg.Database); strings.Contains(s, "field-secret") {
+		t.Errorf("Sprintf(%%v) of the database leaks the password: %s", s)
+	}
+
+	if cfg.ConnectionString != "postgres://voting:url-secret@db/voting" || cfg.Database.Password != "field-secret" {
+		t.Error("Redacted() changed the secrets of the configuration")
+	}
+	// No password stays empty, rather than hinting at one
+	if got := (DatabaseConfig{}).Redacted().Password; got != "" {
+		t.Errorf("Redacted() password = %q, want none", got)
+	}
+}
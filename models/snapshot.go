@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// SnapshotRunning - The run is reading the chain or allocating the votes
+	SnapshotRunning = "running"
+	// SnapshotCompleted - The run stored the votes of every account
+	SnapshotCompleted = "completed"
+	// SnapshotFailed - The run stopped on an error
+	SnapshotFailed = "failed"
+	// SnapshotCancelled - The run stopped because the service shut down
+	SnapshotCancelled = "cancelled"
+)
+
+// Snapshot - The chain state and allocation of a run. A derived snapshot recomputes its parent with another policy.
+type Snapshot struct {
+	ID             int64
+	TrackingNumber string
+	ParentID       *int64
+	Policy         string
+	Status         string
+	Network        string
+	ChainTotalNdau int
+	StartedAt      time.Time
+	FinishedAt     *time.Time
+}
+
+// TableName - Return table name
+func (t Snapshot) TableName() string {
+	return "snapshots"
+}
+
+// SnapshotAccount - An account of a snapshot, with the input and the result of the allocation
+type SnapshotAccount struct {
+	SnapshotID       int64
+	Address          string
+	Balance          int
+	CurrencySeatDate time.Time
+	Class            string
+	Eligible         bool
+	Votes            float64
+	EffectiveVotes   float64
+}
+
+// TableName - Return table name
+func (t SnapshotAccount) TableName() string {
+	return "snapshot_accounts"
+}
+
+// SnapshotDelegation - A delegation applied by the allocation of a snapshot
+type SnapshotDelegation struct {
+	SnapshotID int64
+	Delegator  string
+	Delegate   string
+}
+
+// TableName - Return table name
+func (t SnapshotDelegation) TableName() string {
+	return "snapshot_delegations"
+}
+
+// SnapshotExclusion - An address excluded from the allocation of a snapshot
+type SnapshotExclusion struct {
+	SnapshotID int64
+	Address    string
+	Reason     string
+}
+
+// TableName - Return table name
+func (t SnapshotExclusion) TableName() string {
+	return "snapshot_exclusions"
+}
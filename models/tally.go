@@ -0,0 +1,53 @@
+package models
+
+const (
+	// ChoiceYes - Vote in favor of a proposal
+	ChoiceYes = "yes"
+	// ChoiceNo - Vote against a proposal
+	ChoiceNo = "no"
+	// ChoiceAbstain - Counted for turnout but neither for nor against
+	ChoiceAbstain = "abstain"
+)
+
+// Tally - Voting power cast for each choice
+type Tally struct {
+	Yes     float64
+	No      float64
+	Abstain float64
+	// Turnout is the cast voting power as a fraction of the total voting power
+	Turnout float64
+}
+
+// Cast - Total voting power cast, abstentions included
+func (t Tally) Cast() float64 {
+	return t.Yes + t.No + t.Abstain
+}
+
+// Add - Count voting power for a choice, false when the choice is unknown
+func (t *Tally) Add(choice string, power float64) bool {
+	switch choice {
+	case ChoiceYes:
+		t.Yes += power
+	case ChoiceNo:
+		t.No += power
+	case ChoiceAbstain:
+		t.Abstain += power
+	default:
+		return false
+	}
+	return true
+}
+
+// ProposalTally - Running and frozen results of a proposal
+type ProposalTally struct {
+	ProposalID       int64
+	Status           string
+	TotalVotingPower float64
+	// Live uses the current voting power of each voter
+	Live Tally
+	// Frozen uses the voting power recorded when the proposal was concluded
+	Frozen Tally
+	// FrozenTotalVotingPower is the total voting power recorded with the frozen votes, the base of
+	// their turnout
+	FrozenTotalVotingPower float64
+}
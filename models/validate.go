@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// minAdminTokenLength - Shortest admin bearer token accepted
+const minAdminTokenLength = 16
+
+// ValidationError - Every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+// Error -
+func (t *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(t.Problems, "; "))
+}
+
+// Add - Record a problem
+func (t *ValidationError) Add(format string, args ...interface{}) {
+	t.Problems = append(t.Problems, fmt.Sprintf(format, args...))
+}
+
+// Err - Nil unless a problem was recorded
+func (t *ValidationError) Err() error {
+	if len(t.Problems) == 0 {
+		return nil
+	}
+	return t
+}
+
+// Validate - Check the whole configuration, the returned error lists every problem
+func (t *Config) Validate() error {
+	v := &ValidationError{}
+
+	switch t.Database.Driver {
+	case "postgres":
+		if t.ConnectionString == "" && t.Database.Host == "" {
+			v.Add("a connection string or a database host is required")
+		}
+	case "sqlite":
+		if t.Database.Name == "" {
+			v.Add("database.name is required, the file of the sqlite database or :memory:")
+		}
+	case "memory":
+	default:
+		v.Add("database.driver must be postgres, sqlite or memory, not '%s'", t.Database.Driver)
+	}
+	checkPort(v, "port", t.Port, false)
+	checkPort(v, "database.port", t.Database.Port, true)
+	switch t.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		v.Add("log_level must be debug, info, warn or error, not '%s'", t.LogLevel)
+	}
+	switch t.Database.LogLevel {
+	case "silent", "error", "warn", "info":
+	default:
+		v.Add("database.log_level must be silent, error, warn or info, not '%s'", t.Database.LogLevel)
+	}
+	for _, field := range []struct {
+		name string
+		val  int
+	}{
+		{"database.slow_query_ms", t.Database.SlowQueryMs},
+		{"database.max_open_conns", t.Database.MaxOpenConns},
+		{"database.max_idle_conns", t.Database.MaxIdleConns},
+		{"database.conn_max_lifetime_seconds", t.Database.ConnMaxLifetimeSeconds},
+		{"database.conn_max_idle_time_seconds", t.Database.ConnMaxIdleTimeSeconds},
+		{"database.retries", t.Database.Retries},
+		{"database.startup_timeout_seconds", t.Database.StartupTimeoutSeconds},
+		{"run.batch_pause_ms", t.Run.BatchPauseMs},
+		{"run.snapshot_retention_days", t.Run.SnapshotRetentionDays},
+	} {
+		if field.val < 0 {
+			v.Add("%s must not be negative", field.name)
+		}
+	}
+
+	for _, node := range t.Nodes {
+		if u, err := url.Parse(node); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.Add("node '%s' is not an http(s) URL", node)
+		}
+	}
+	if len(t.Nodes) > 0 && t.Network == "" {
+		v.Add("network is required with nodes")
+	}
+	if t.Run.PageSize <= 0 {
+		v.Add("run.page_size must be positive")
+	}
+	if t.Run.BatchSize <= 0 || t.Run.BatchSize > 1000 {
+		v.Add("run.batch_size must be between 1 and 1000")
+	}
+	if t.Run.RequestsPerSecond < 0 {
+		v.Add("run.requests_per_second must not be negative")
+	}
+
+	operators := make([]string, 0, len(t.Admin.Tokens))
+	for name := range t.Admin.Tokens {
+		operators = append(operators, name)
+	}
+	sort.Strings(operators)
+	seen := map[string]string{}
+	for _, name := range operators {
+		token := t.Admin.Tokens[name]
+		switch {
+		case strings.TrimSpace(name) == "":
+			v.Add("admin tokens need an operator name")
+		case len(token) < minAdminTokenLength:
+			v.Add("the admin token of '%s' must be at least %d characters", name, minAdminTokenLength)
+		case seen[token] != "":
+			v.Add("operators '%s' and '%s' share an admin token", seen[token], name)
+		}
+		seen[token] = name
+	}
+	if t.Admin.TLSCertFile != "" && t.Admin.TLSKeyFile == "" || t.Admin.TLSCertFile == "" && t.Admin.TLSKeyFile != "" {
+		v.Add("admin.tls_cert_file and admin.tls_key_file go together")
+	}
+	if t.Admin.ClientCAFile != "" && !t.Admin.TLS() {
+		v.Add("admin.client_ca_file requires the admin TLS listener")
+	}
+	if t.Admin.TLS() {
+		checkPort(v, "admin.port", t.Admin.Port, false)
+		if t.Admin.Port == t.Port {
+			v.Add("admin.port must differ from port")
+		}
+	}
+
+	if t.Health.TimeoutSeconds <= 0 {
+		v.Add("health.timeout_seconds must be positive")
+	}
+	if t.Tracing.Enabled && t.Tracing.Endpoint == "" {
+		v.Add("tracing.endpoint is required when tracing is enabled")
+	}
+	if t.Tracing.SampleRatio < 0 || t.Tracing.SampleRatio > 1 {
+		v.Add("tracing.sample_ratio must be between 0 and 1")
+	}
+
+	if _, err := t.Policy(t.DefaultPolicy); err != nil {
+		v.Add("default_policy: %v", err)
+	}
+	names := make([]string, 0, len(t.Policies))
+	for name := range t.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		policy := t.Policies[name]
+		if policy.SeatVotes < 0 || policy.BalanceVotes < 0 || policy.SeniorityVotes < 0 || policy.SenioritySeats < 0 {
+			v.Add("policy '%s' must not have negative votes or seats", name)
+		}
+		if policy.SeniorityVotes > 0 && policy.SenioritySeats == 0 {
+			v.Add("policy '%s' has seniority votes but no seniority seats", name)
+		}
+	}
+
+	for i, exclusion := range t.Exclusions {
+		if exclusion.Address == "" {
+			v.Add("exclusion %d has no address", i)
+		}
+	}
+
+	return v.Err()
+}
+
+func checkPort(v *ValidationError, name string, port int, optional bool) {
+	if optional && port == 0 {
+		return
+	}
+	if port <= 0 || port > 65535 {
+		v.Add("%s must be between 1 and 65535", name)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// validConfig - The defaults with the database they need
+func validConfig() Config {
+	cfg := DefaultConfig()
+	cfg.ConnectionString = "host=localhost"
+	return cfg
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"connection string": validConfig(),
+		"database host": func() Config {
+			cfg := DefaultConfig()
+			cfg.Database.Host = "db"
+			return cfg
+		}(),
+		"sqlite": func() Config {
+			cfg := DefaultConfig()
+			cfg.Database.Driver, cfg.Database.Name = "sqlite", ":memory:"
+			return cfg
+		}(),
+		"memory": func() Config {
+			cfg := DefaultConfig()
+			cfg.Database.Driver = "memory"
+			return cfg
+		}(),
+	} {
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: Validate() = %v, want nil", name, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string
+	}{
+		{"no database", func(cfg *Config) { cfg.ConnectionString = "" }, "a connection string or a database host is required"},
+		{"sqlite without a file", func(cfg *Config) { cfg.Database.Driver = "sqlite" }, "database.name is required"},
+		{"unknown driver", func(cfg *Config) { cfg.Database.Driver = "mysql" }, "database.driver must be postgres, sqlite or memory, not 'mysql'"},
+		{"no port", func(cfg *Config) { cfg.Port = 0 }, "port must be between 1 and 65535"},
+		{"database port too large", func(cfg *Config) { cfg.Database.Port = 70000 }, "database.port must be between 1 and 65535"},
+		{"log level", func(cfg *Config) { cfg.LogLevel = "trace" }, "log_level must be debug, info, warn or error, not 'trace'"},
+		{"database log level", func(cfg *Config) { cfg.Database.LogLevel = "debug" }, "database.log_level must be silent, error, warn or info, not 'debug'"},
+		{"negative pool", func(cfg *Config) { cfg.Database.MaxOpenConns = -1 }, "database.max_open_conns must not be negative"},
+		{"negative retries", func(cfg *Config) { cfg.Database.Retries = -1 }, "database.retries must not be negative"},
+		{"negative pause", func(cfg *Config) { cfg.Run.BatchPauseMs = -1 }, "run.batch_pause_ms must not be negative"},
+		{"negative retention", func(cfg *Config) { cfg.Run.SnapshotRetentionDays = -1 }, "run.snapshot_retention_days must not be negative"},
+		{"node url", func(cfg *Config) { cfg.Network, cfg.Nodes = "mainnet", []string{"node.ndau.tech"} }, "node 'node.ndau.tech' is not an http(s) URL"},
+		{"nodes without network", func(cfg *Config) { cfg.Nodes = []string{"https://node.ndau.tech"} }, "network is required with nodes"},
+		{"page size", func(cfg *Config) { cfg.Run.PageSize = 0 }, "run.page_size must be positive"},
+		{"batch size", func(cfg *Config) { cfg.Run.BatchSize = 1001 }, "run.batch_size must be between 1 and 1000"},
+		{"rate limit", func(cfg *Config) { cfg.Run.RequestsPerSecond = -1 }, "run.requests_per_second must not be negative"},
+		{"half of the tls pair", func(cfg *Config) { cfg.Admin.TLSCertFile = "tls.crt" }, "admin.tls_cert_file and admin.tls_key_file go together"},
+		{"client ca without tls", func(cfg *Config) { cfg.Admin.ClientCAFile = "ca.crt" }, "admin.client_ca_file requires the admin TLS listener"},
+		{"admin port", func(cfg *Config) {
+			cfg.Admin.TLSCertFile, cfg.Admin.TLSKeyFile, cfg.Admin.Port = "tls.crt", "tls.key", cfg.Port
+		}, "admin.port must differ from port"},
+		{"health timeout", func(cfg *Config) { cfg.Health.TimeoutSeconds = 0 }, "health.timeout_seconds must be positive"},
+		{"tracing endpoint", func(cfg *Config) { cfg.Tracing.Enabled = true }, "tracing.endpoint is required when tracing is enabled"},
+		{"sample ratio", func(cfg *Config) { cfg.Tracing.SampleRatio = 1.5 }, "tracing.sample_ratio must be between 0 and 1"},
+		{"unknown default policy", func(cfg *Config) { cfg.DefaultPolicy = "missing" }, "default_policy:"},
+		{"negative votes", func(cfg *Config) {
+			cfg.Policies = map[string]AllocationPolicy{"custom": {SeatVotes: -1}}
+		}, "policy 'custom' must not have negative votes or seats"},
+		{"seniority without seats", func(cfg *Config) {
+			cfg.Policies = map[string]AllocationPolicy{"custom": {SeniorityVotes: 10}}
+		}, "policy 'custom' has seniority votes but no seniority seats"},
+		{"exclusion without address", func(cfg *Config) { cfg.Exclusions = []Exclusion{{}} }, "exclusion 0 has no address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Port = 0
+	cfg.LogLevel = "trace"
+	cfg.Run.PageSize = 0
+
+	err := cfg.Validate()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate() = %v, want a ValidationError", err)
+	}
+	if len(invalid.Problems) != 3 {
+		t.Errorf("problems = %q, want the port, the log level and the page size", invalid.Problems)
+	}
+	if (&ValidationError{}).Err() != nil {
+		t.Error("Err() of no problem is not nil")
+	}
+}
+
+func TestDataWithDefaults(t *testing.T) {
+	cfg := validConfig()
+	cfg.Network, cfg.NodeAPI = "mainnet", "https://mainnet-0.ndau.tech"
+
+	got := Data{}.WithDefaults(&cfg)
+	want := Data{Network: "mainnet", NodeAPI: "https://mainnet-0.ndau.tech", Limit: cfg.Run.PageSize, StartAfterKey: "-"}
+	if got != want {
+		t.Errorf("WithDefaults() = %+v, want %+v", got, want)
+	}
+
+	// The fields of the request override the configuration
+	request := Data{Network: "testnet", NodeAPI: "https://testnet-0.ndau.tech", Limit: 10, StartAfterKey: "ndaa", Policy: "custom", SnapshotID: 7}
+	if got := request.WithDefaults(&cfg); got != request {
+		t.Errorf("WithDefaults() = %+v, want the request %+v", got, request)
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Vote - A ballot cast by an account on a proposal
+type Vote struct {
+	ID          int64
+	ProposalID  int64
+	UserAddress string
+	Choice      string
+	CastAt      time.Time
+	// ConcludedVotes is the voting power frozen when the proposal concluded
+	ConcludedVotes *float64
+}
+
+// TableName - Return table name
+func (t Vote) TableName() string {
+	return "votes"
+}
+
+// VoteRequest - A vote signed with one of the account's validation keys
+type VoteRequest struct {
+	Address string
+	Choice  string
+	// Timestamp is signed with a one second resolution, a vote is only accepted if it is newer than the recorded one
+	Timestamp time.Time
+	// Signature of SignedMessage
+	Signature string
+}
+
+// SignedMessage - The bytes signed by the voter. The network keeps a vote of one network from being
+// replayed on another, where the proposal ids overlap.
+func (t VoteRequest) SignedMessage(network string, proposalID int64) []byte {
+	return []byte(fmt.Sprintf("ndau-dao-vote:%s:%d:%s:%s:%d", network, proposalID, t.Address, t.Choice, t.Timestamp.Unix()))
+}
@@ -0,0 +1,276 @@
+package serving
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+const maxAdminBodySize = 64 << 10
+
+// exclusionList - The exclusion list as seen by a run
+type exclusionList struct {
+	// Configured exclusions come from the configuration file and cannot be edited here
+	Configured []models.Exclusion
+	Managed    []models.Exclusion
+}
+
+// registerAdminRoutes - Endpoints for manual operations, to be served behind requireAdmin
+func (k *KnClient) registerAdminRoutes(ctx context.Context, mux *http.ServeMux, repo dal.Repo, store *configuration.Store) {
+	mux.HandleFunc("/admin/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "only POST method is supported")
+			return
+		}
+		k.triggerRun(ctx, w, r, repo, store.Load())
+	})
+	mux.HandleFunc("/admin/proposals/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "only POST method is supported")
+			return
+		}
+		k.reconcludeProposal(w, r, repo)
+	})
+	mux.HandleFunc("/admin/snapshots/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "only POST method is supported")
+			return
+		}
+		k.recomputeSnapshot(w, r, repo, store.Load())
+	})
+	mux.HandleFunc("/admin/exclusions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			k.listExclusions(w, r, repo, store.Load())
+		case http.MethodPost:
+			k.addExclusion(w, r, repo)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, http.StatusMethodNotAllowed, "only GET and POST methods are supported")
+		}
+	})
+	mux.HandleFunc("/admin/exclusions/audit", k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+		k.listExclusionAudits(w, r, repo)
+	}))
+	mux.HandleFunc("/admin/exclusions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.Header().Set("Allow", http.MethodDelete)
+			writeError(w, http.StatusMethodNotAllowed, "only DELETE method is supported")
+			return
+		}
+		k.removeExclusion(w, r, repo)
+	})
+}
+
+// triggerRun - POST /admin/runs starts a run in the background
+func (k *KnClient) triggerRun(ctx context.Context, w http.ResponseWriter, r *http.Request, repo dal.Repo, cfg *models.Config) {
+	var data models.Data
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxAdminBodySize)).Decode(&data); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed run request")
+		return
+	}
+	if resolved := data.WithDefaults(cfg); data.SnapshotID == 0 && (resolved.Network == "" || resolved.NodeAPI == "") {
+		writeError(w, http.StatusBadRequest, "network and node API are neither requested nor configured")
+		return
+	}
+
+	if !k.runMu.TryLock() {
+		writeError(w, http.StatusConflict, "a run is already in progress")
+		return
+	}
+
+	trackingNumber := uuid.New().String()
+	k.Log.Infof("%s | %s triggered a run on %s", trackingNumber, adminActor(r), data.Network)
+	go func() {
+		defer k.runMu.Unlock()
+		if err := k.ProcessEvent(context.WithValue(ctx, "tracking_number", trackingNumber), &data, repo, cfg); err != nil {
+			k.Log.Errorf("%s | Failed to process the triggered run: %v", trackingNumber, err)
+		}
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"TrackingNumber": trackingNumber})
+}
+
+// reconcludeProposal - POST /admin/proposals/{id}/reconclude freezes the votes again
+func (k *KnClient) reconcludeProposal(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/proposals/"), "/")
+	proposalID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || len(parts) != 2 || parts[1] != "reconclude" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	ctx := r.Context()
+	proposal, err := repo.GetProposal(ctx, proposalID)
+	if errors.Is(err, dal.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "proposal not found")
+		return
+	} else if err != nil {
+		k.Log.Errorf("Failed to read proposal %d: %v", proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to read proposal")
+		return
+	}
+	if proposal.Status(time.Now()) != models.ProposalStatusConcluded {
+		writeError(w, http.StatusConflict, "proposal is not concluded")
+		return
+	}
+
+	trackingNumber := uuid.New().String()
+	k.Log.Infof("%s | %s reconcludes proposal %d", trackingNumber, adminActor(r), proposalID)
+	if err := repo.ReconcludeProposal(context.WithValue(ctx, "tracking_number", trackingNumber), proposalID); err != nil {
+		k.Log.Errorf("%s | Failed to reconclude proposal %d: %v", trackingNumber, proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to reconclude proposal")
+		return
+	}
+
+	tally, err := repo.ConcludedTally(ctx, proposalID)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to tally concluded votes of proposal %d: %v", trackingNumber, proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to tally proposal")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tally)
+}
+
+// recomputeSnapshot - POST /admin/snapshots/{id}/recompute?policy= reruns the allocation of a stored snapshot
+func (k *KnClient) recomputeSnapshot(w http.ResponseWriter, r *http.Request, repo dal.Repo, cfg *models.Config) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/snapshots/"), "/")
+	snapshotID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || len(parts) != 2 || parts[1] != "recompute" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	policy := r.URL.Query().Get("policy")
+	if _, err := cfg.Policy(policy); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trackingNumber := uuid.New().String()
+	k.Log.Infof("%s | %s recomputes snapshot %d", trackingNumber, adminActor(r), snapshotID)
+	snapshot, err := k.Recompute(context.WithValue(r.Context(), "tracking_number", trackingNumber), repo, cfg, snapshotID, policy)
+	if errors.Is(err, dal.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "snapshot not found")
+		return
+	} else if errors.Is(err, ErrSnapshotIncomplete) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to recompute snapshot")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, snapshot)
+}
+
+// listExclusions - GET /admin/exclusions
+func (k *KnClient) listExclusions(w http.ResponseWriter, r *http.Request, repo dal.Repo, cfg *models.Config) {
+	managed, err := repo.ListExclusions(r.Context())
+	if err != nil {
+		k.Log.Errorf("Failed to list exclusions: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list exclusions")
+		return
+	}
+
+	configured := cfg.Exclusions
+	if configured == nil {
+		configured = []models.Exclusion{}
+	}
+	writeJSON(w, http.StatusOK, exclusionList{
+		Configured: configured,
+		Managed:    managed,
+	})
+}
+
+// addExclusion - POST /admin/exclusions
+func (k *KnClient) addExclusion(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	actor := adminActor(r)
+	if actor == "" {
+		// Exclusions are only changed by an authenticated operator, named in the audit trail
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var exclusion models.Exclusion
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxAdminBodySize)).Decode(&exclusion); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed exclusion")
+		return
+	}
+	if exclusion.Address == "" || exclusion.Reason == "" {
+		writeError(w, http.StatusBadRequest, "address and reason are required")
+		return
+	}
+
+	if err := repo.AddExclusion(r.Context(), &exclusion, actor); err != nil {
+		k.Log.Errorf("Failed to exclude %s: %v", exclusion.Address, err)
+		writeError(w, http.StatusInternalServerError, "failed to add exclusion")
+		return
+	}
+
+	k.Log.Infof("%s excluded %s: %s", actor, exclusion.Address, exclusion.Reason)
+	writeJSON(w, http.StatusCreated, exclusion)
+}
+
+// removeExclusion - DELETE /admin/exclusions/{address}?reason=
+func (k *KnClient) removeExclusion(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	actor := adminActor(r)
+	if actor == "" {
+		// Exclusions are only changed by an authenticated operator, named in the audit trail
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	address := strings.TrimPrefix(r.URL.Path, "/admin/exclusions/")
+	reason := r.URL.Query().Get("reason")
+	if address == "" || strings.Contains(address, "/") || reason == "" {
+		writeError(w, http.StatusBadRequest, "address and reason are required")
+		return
+	}
+
+	err := repo.RemoveExclusion(r.Context(), address, reason, actor)
+	if errors.Is(err, dal.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "address is not excluded")
+		return
+	} else if err != nil {
+		k.Log.Errorf("Failed to remove the exclusion of %s: %v", address, err)
+		writeError(w, http.StatusInternalServerError, "failed to remove exclusion")
+		return
+	}
+
+	k.Log.Infof("%s removed the exclusion of %s: %s", actor, address, reason)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listExclusionAudits - GET /admin/exclusions/audit?address=&limit=
+func (k *KnClient) listExclusionAudits(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	q := r.URL.Query()
+	limit, err := intParam(q.Get("limit"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	audits, err := repo.ListExclusionAudits(r.Context(), q.Get("address"), limit)
+	if err != nil {
+		k.Log.Errorf("Failed to list exclusion audits: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list exclusion audits")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, audits)
+}
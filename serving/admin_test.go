@@ -0,0 +1,198 @@
+package serving
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+const adminToken = "ops-0123456789abcdef"
+
+// newAdminServer - The routes of the service over an in-memory repository, the admin ones behind the tokens of two operators
+func newAdminServer(t *testing.T) (*httptest.Server, *dal.Memory, *KnClient) {
+	t.Helper()
+
+	cfg := models.DefaultConfig()
+	cfg.Admin.Tokens = map[string]string{"ops": adminToken, "audit": "audit-0123456789abcdef"}
+	cfg.Exclusions = []models.Exclusion{{Address: "configured", Reason: "treasury"}}
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	repo := dal.NewMemory()
+	mux, _ := k.routes(context.Background(), repo, configuration.NewStore(&cfg))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, repo, k
+}
+
+// adminRequest - Send an admin request with a bearer token, none when empty, and decode the answer
+func adminRequest(t *testing.T, server *httptest.Server, method, path, token string, body, v interface{}) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s = %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: failed decoding the answer: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestExclusionEndpoints(t *testing.T) {
+	server, repo, _ := newAdminServer(t)
+	exclusion := models.Exclusion{Address: "whale", Reason: "custody"}
+
+	if status := adminRequest(t, server, http.MethodPost, "/admin/exclusions", adminToken, exclusion, nil); status != http.StatusCreated {
+		t.Fatalf("POST /admin/exclusions = %d, want %d", status, http.StatusCreated)
+	}
+	var list exclusionList
+	if status := adminRequest(t, server, http.MethodGet, "/admin/exclusions", adminToken, nil, &list); status != http.StatusOK {
+		t.Fatalf("GET /admin/exclusions = %d", status)
+	}
+	if len(list.Configured) != 1 || list.Configured[0].Address != "configured" {
+		t.Errorf("configured exclusions = %+v, want the configuration file ones", list.Configured)
+	}
+	if len(list.Managed) != 1 || list.Managed[0].Address != "whale" || list.Managed[0].CreatedBy != "token:ops" {
+		t.Errorf("managed exclusions = %+v, want whale created by ops", list.Managed)
+	}
+
+	if status := adminRequest(t, server, http.MethodDelete, "/admin/exclusions/whale?reason=released", adminToken, nil, nil); status != http.StatusNoContent {
+		t.Errorf("DELETE /admin/exclusions/whale = %d, want %d", status, http.StatusNoContent)
+	}
+	if status := adminRequest(t, server, http.MethodDelete, "/admin/exclusions/whale?reason=released", adminToken, nil, nil); status != http.StatusNotFound {
+		t.Errorf("DELETE of an address not excluded = %d, want %d", status, http.StatusNotFound)
+	}
+
+	var audits []models.ExclusionAudit
+	if status := adminRequest(t, server, http.MethodGet, "/admin/exclusions/audit?address=whale", adminToken, nil, &audits); status != http.StatusOK {
+		t.Fatalf("GET /admin/exclusions/audit = %d", status)
+	}
+	actions := []string{}
+	for _, audit := range audits {
+		actions = append(actions, audit.Action+":"+audit.Reason+":"+audit.Actor)
+	}
+	if want := []string{"remove:released:token:ops", "add:custody:token:ops"}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("audit trail = %v, want %v", actions, want)
+	}
+
+	if managed, err := repo.ListExclusions(runContext()); err != nil || len(managed) != 0 {
+		t.Errorf("ListExclusions() = %v, %v, want none left", managed, err)
+	}
+}
+
+func TestExclusionEndpointsRejectBadRequests(t *testing.T) {
+	server, _, _ := newAdminServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		{"no reason", http.MethodPost, "/admin/exclusions", models.Exclusion{Address: "whale"}, http.StatusBadRequest},
+		{"no address", http.MethodPost, "/admin/exclusions", models.Exclusion{Reason: "custody"}, http.StatusBadRequest},
+		{"removal without reason", http.MethodDelete, "/admin/exclusions/whale", nil, http.StatusBadRequest},
+		{"unsupported method", http.MethodPut, "/admin/exclusions", nil, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := adminRequest(t, server, tt.method, tt.path, adminToken, tt.body, nil); status != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, status, tt.want)
+			}
+		})
+	}
+}
+
+// TestExclusionWritesRequireAnOperator - The exclusion list cannot change without an authenticated operator,
+// even when the admin routes are served without requireAdmin
+func TestExclusionWritesRequireAnOperator(t *testing.T) {
+	cfg := models.DefaultConfig()
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	repo := dal.NewMemory()
+	if err := repo.AddExclusion(runContext(), &models.Exclusion{Address: "whale", Reason: "custody"}, "token"); err != nil {
+		t.Fatalf("AddExclusion() = %v", err)
+	}
+	mux := http.NewServeMux()
+	k.registerAdminRoutes(context.Background(), mux, repo, configuration.NewStore(&cfg))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	if status := adminRequest(t, server, http.MethodPost, "/admin/exclusions", "", models.Exclusion{Address: "other", Reason: "custody"}, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /admin/exclusions without operator = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status := adminRequest(t, server, http.MethodDelete, "/admin/exclusions/whale?reason=released", "", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("DELETE /admin/exclusions/whale without operator = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if managed, err := repo.ListExclusions(runContext()); err != nil || len(managed) != 1 || managed[0].Address != "whale" {
+		t.Errorf("ListExclusions() = %v, %v, want whale alone", managed, err)
+	}
+}
+
+func TestExclusionsMergeTheConfigurationAndTheTable(t *testing.T) {
+	cfg := models.DefaultConfig()
+	cfg.Exclusions = []models.Exclusion{{Address: "a", Reason: "configured"}, {Address: "b", Reason: "configured"}}
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	repo := dal.NewMemory()
+	for _, address := range []string{"b", "c"} {
+		if err := repo.AddExclusion(runContext(), &models.Exclusion{Address: address, Reason: "managed"}, "token"); err != nil {
+			t.Fatalf("AddExclusion() = %v", err)
+		}
+	}
+
+	excluded, err := k.exclusions(runContext(), &cfg, repo)
+	if err != nil {
+		t.Fatalf("exclusions() = %v", err)
+	}
+	want := map[string]string{"a": "configured", "b": "managed", "c": "managed"}
+	if len(excluded) != len(want) {
+		t.Errorf("exclusions() = %v, want %v", excluded, want)
+	}
+	for address, reason := range want {
+		if excluded[address] != reason {
+			t.Errorf("exclusion of %s = %q, want %q", address, excluded[address], reason)
+		}
+	}
+}
+
+func TestAdminEndpointsRequireTheToken(t *testing.T) {
+	server, _, _ := newAdminServer(t)
+
+	for _, token := range []string{"", "wrong"} {
+		if status := adminRequest(t, server, http.MethodPost, "/admin/exclusions", token, models.Exclusion{Address: "whale", Reason: "custody"}, nil); status != http.StatusUnauthorized {
+			t.Errorf("POST /admin/exclusions with token %q = %d, want %d", token, status, http.StatusUnauthorized)
+		}
+	}
+}
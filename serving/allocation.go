@@ -0,0 +1,142 @@
+package serving
+
+import (
+	"sort"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// unseated - Accounts whose currency seat date is too old to be a real seat
+func unseated(accounts []models.ChainAccount) models.Cached {
+	var void struct{}
+	unseatList := models.Cached{}
+	for _, account := range accounts {
+		if account.CurrencySeatDate.Before(models.SeatedSince) {
+			unseatList[account.Address] = void
+		}
+	}
+
+	return unseatList
+}
+
+// allocate - Compute the voting power of each account under a policy. It only depends on its
+// arguments, so that a stored snapshot gives the same result as the run that recorded it.
+//   - SeatVotes are assigned equally to each currency seat
+//   - BalanceVotes are assigned proportionally to each address based on its share of all ndau in circulation
+//   - SeniorityVotes are assigned equally to the SenioritySeats oldest currency seats, ties broken by address
+//   - Accounts excluded by the eligibility policy get no vote and no seat
+//
+// The result does not depend on the order of the accounts, a run and a snapshot read back by address
+// agree. With fewer seats than SenioritySeats only the seats get a share of the seniority votes, and
+// a chain without ndau gives no balance votes.
+func allocate(accounts []models.ChainAccount, unseatList models.Cached, totalNdau int, policy models.AllocationPolicy) []models.VotingSetup {
+	seated := func(account models.ChainAccount) bool {
+		_, unseated := unseatList[account.Address]
+		return account.Eligible && !unseated
+	}
+
+	seats := []int{}
+	for i := range accounts {
+		if seated(accounts[i]) {
+			seats = append(seats, i)
+		}
+	}
+
+	votes := make([]models.VotingSetup, len(accounts))
+	for i, account := range accounts {
+		var power float64
+		if account.Eligible {
+			if totalNdau > 0 {
+				power = policy.BalanceVotes * float64(account.Balance) / float64(totalNdau)
+			}
+			if seated(account) {
+				power += policy.SeatVotes / float64(len(seats))
+			}
+		}
+
+		votes[i] = models.VotingSetup{
+			Address:           account.Address,
+			CurrencySeatDate:  account.CurrencySeatDate,
+			Votes:             power,
+			Eligible:          account.Eligible,
+			EligibilityReason: account.Class,
+		}
+	}
+
+	// Find the oldest currency seats
+	sort.SliceStable(seats, func(i, j int) bool {
+		a, b := accounts[seats[i]], accounts[seats[j]]
+		if !a.CurrencySeatDate.Equal(b.CurrencySeatDate) {
+			return a.CurrencySeatDate.Before(b.CurrencySeatDate)
+		}
+		return a.Address < b.Address
+	})
+	if len(seats) > policy.SenioritySeats {
+		seats = seats[:policy.SenioritySeats]
+	}
+	for _, i := range seats {
+		votes[i].Votes += policy.SeniorityVotes / float64(policy.SenioritySeats)
+	}
+
+	return votes
+}
+
+// applyDelegations - Set the effective voting power of each account. A delegator whose chain
+// resolves to an account of the list gives it all of its power, otherwise it keeps it.
+func applyDelegations(votes []models.VotingSetup, delegations models.Delegations, maxDepth int) (delegated int) {
+	index := map[string]int{}
+	for i := range votes {
+		votes[i].EffectiveVotes = votes[i].Votes
+		index[votes[i].Address] = i
+	}
+
+	for i := range votes {
+		delegate, ok := delegations.Resolve(votes[i].Address, maxDepth)
+		if !ok {
+			continue
+		}
+		j, found := index[delegate]
+		if !found {
+			continue
+		}
+		votes[j].EffectiveVotes += votes[i].Votes
+		votes[i].EffectiveVotes -= votes[i].Votes
+		delegated++
+	}
+
+	return delegated
+}
+
+// snapshotAccounts - The accounts of a snapshot, with the chain state and the allocation
+func snapshotAccounts(accounts []models.ChainAccount, votes []models.VotingSetup) []models.SnapshotAccount {
+	rows := make([]models.SnapshotAccount, len(accounts))
+	for i, account := range accounts {
+		rows[i] = models.SnapshotAccount{
+			Address:          account.Address,
+			Balance:          account.Balance,
+			CurrencySeatDate: account.CurrencySeatDate,
+			Class:            account.Class,
+			Eligible:         account.Eligible,
+			Votes:            votes[i].Votes,
+			EffectiveVotes:   votes[i].EffectiveVotes,
+		}
+	}
+
+	return rows
+}
+
+// chainAccounts - The chain state recorded in a snapshot
+func chainAccounts(rows []models.SnapshotAccount) []models.ChainAccount {
+	accounts := make([]models.ChainAccount, len(rows))
+	for i, row := range rows {
+		accounts[i] = models.ChainAccount{
+			Address:          row.Address,
+			Balance:          row.Balance,
+			CurrencySeatDate: row.CurrencySeatDate,
+			Class:            row.Class,
+			Eligible:         row.Eligible,
+		}
+	}
+
+	return accounts
+}
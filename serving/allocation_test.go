@@ -0,0 +1,133 @@
+package serving
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// seat - An eligible account seated on a day of 2019
+func seat(address string, day, balance int) models.ChainAccount {
+	return models.ChainAccount{
+		Address:          address,
+		Balance:          balance,
+		CurrencySeatDate: time.Date(2019, time.January, day, 0, 0, 0, 0, time.UTC),
+		Class:            "regular",
+		Eligible:         true,
+	}
+}
+
+// votesOf - The voting power allocated to each address
+func votesOf(votes []models.VotingSetup) map[string]float64 {
+	power := map[string]float64{}
+	for _, vote := range votes {
+		power[vote.Address] = vote.Votes
+	}
+	return power
+}
+
+func TestAllocateBreaksSeniorityTiesByAddress(t *testing.T) {
+	policy := models.DefaultAllocationPolicy()
+	accounts := []models.ChainAccount{seat("e", 1, 0), seat("d", 1, 0), seat("c", 1, 0), seat("b", 1, 0), seat("a", 2, 0)}
+
+	// The same seats in every order get the same votes: a snapshot sorted by address recomputes the run
+	want := map[string]float64{"b": 1600000, "c": 1600000, "d": 1600000, "e": 600000, "a": 600000}
+	for _, order := range [][]int{{0, 1, 2, 3, 4}, {4, 3, 2, 1, 0}, {2, 4, 0, 3, 1}} {
+		list := make([]models.ChainAccount, len(order))
+		for i, j := range order {
+			list[i] = accounts[j]
+		}
+		got := votesOf(allocate(list, models.Cached{}, 1, policy))
+		for address, power := range want {
+			if math.Abs(got[address]-power) > 1e-6 {
+				t.Errorf("order %v: votes of %s = %v, want %v", order, address, got[address], power)
+			}
+		}
+	}
+}
+
+func TestAllocateWithFewerSeatsThanSenioritySeats(t *testing.T) {
+	policy := models.DefaultAllocationPolicy()
+	ineligible := seat("a", 1, 1000)
+	ineligible.Eligible = false
+	unseatedAccount := seat("b", 1, 1000)
+	accounts := []models.ChainAccount{ineligible, unseatedAccount, seat("c", 2, 1000), seat("d", 3, 1000)}
+
+	got := votesOf(allocate(accounts, models.Cached{"b": {}}, 4000, policy))
+
+	// Each seat gets one share of the seniority votes, the share of the missing seat is not handed out
+	// to the accounts that are not seated
+	want := map[string]float64{"a": 0, "b": 750000, "c": 750000 + 1500000 + 1000000, "d": 750000 + 1500000 + 1000000}
+	for address, power := range want {
+		if math.Abs(got[address]-power) > 1e-6 {
+			t.Errorf("votes of %s = %v, want %v", address, got[address], power)
+		}
+	}
+
+	// Without any seat only the balances count
+	got = votesOf(allocate([]models.ChainAccount{unseatedAccount}, models.Cached{"b": {}}, 1000, policy))
+	if got["b"] != policy.BalanceVotes {
+		t.Errorf("votes of the only account = %v, want %v", got["b"], policy.BalanceVotes)
+	}
+}
+
+func TestAllocateWithoutNdau(t *testing.T) {
+	policy := models.DefaultAllocationPolicy()
+	accounts := []models.ChainAccount{seat("a", 1, 0), seat("b", 2, 0)}
+
+	// No balance votes rather than NaN when the chain reports no ndau
+	for _, vote := range allocate(accounts, models.Cached{}, 0, policy) {
+		want := policy.SeatVotes/2 + policy.SeniorityVotes/3
+		if math.IsNaN(vote.Votes) || math.Abs(vote.Votes-want) > 1e-6 {
+			t.Errorf("votes of %s = %v, want %v", vote.Address, vote.Votes, want)
+		}
+	}
+}
+
+func TestApplyDelegations(t *testing.T) {
+	votes := []models.VotingSetup{
+		{Address: "a", Votes: 1},
+		{Address: "b", Votes: 2},
+		{Address: "c", Votes: 4},
+		{Address: "x", Votes: 8},
+		{Address: "y", Votes: 16},
+		{Address: "z", Votes: 32},
+	}
+	delegations := models.Delegations{
+		"a": "b", "b": "c", // a chain ending with c
+		"x": "y", "y": "x", // a cycle, both keep their power
+		"z": "unlisted", // a delegate outside of the list, z keeps its power
+	}
+
+	delegated := applyDelegations(votes, delegations, models.MaxDelegationDepth)
+	if delegated != 2 {
+		t.Errorf("applyDelegations() = %d delegations, want 2", delegated)
+	}
+	want := map[string]float64{"a": 0, "b": 0, "c": 7, "x": 8, "y": 16, "z": 32}
+	for _, vote := range votes {
+		if vote.EffectiveVotes != want[vote.Address] {
+			t.Errorf("effective votes of %s = %v, want %v", vote.Address, vote.EffectiveVotes, want[vote.Address])
+		}
+	}
+}
+
+// TestFrozenPowerFollowsTheEffectiveVotes - The power frozen for a voter is its effective voting power
+// when none of its delegators vote, the rule of models.Delegations.ResolveVoter
+func TestFrozenPowerFollowsTheEffectiveVotes(t *testing.T) {
+	votes := []models.VotingSetup{{Address: "a", Votes: 1}, {Address: "b", Votes: 2}, {Address: "c", Votes: 4}}
+	delegations := models.Delegations{"a": "b", "b": "c"}
+	applyDelegations(votes, delegations, models.MaxDelegationDepth)
+
+	frozen := map[string]float64{}
+	voted := map[string]struct{}{"c": {}}
+	for _, vote := range votes {
+		if voter, ok := delegations.ResolveVoter(vote.Address, voted, models.MaxDelegationDepth); ok {
+			frozen[voter] += vote.Votes
+		}
+	}
+	if frozen["c"] != votes[2].EffectiveVotes {
+		t.Errorf("frozen power of c = %v, want its effective votes %v", frozen["c"], votes[2].EffectiveVotes)
+	}
+}
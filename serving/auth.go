@@ -0,0 +1,107 @@
+package serving
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+type contextKey string
+
+// actorKey - Context key of the authenticated operator
+const actorKey contextKey = "admin_actor"
+
+// requireAdmin - Reject requests carrying neither the bearer token of an operator nor a verified client certificate
+func (k *KnClient) requireAdmin(cfg models.AdminConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := authenticate(r, cfg)
+		if !ok {
+			k.Log.Warnf("Rejected unauthenticated admin request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey, actor)))
+	})
+}
+
+// authenticate - Identify the operator from its client certificate or bearer token
+func authenticate(r *http.Request, cfg models.AdminConfig) (string, bool) {
+	// The TLS listener only verifies chains against the client CA
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return "cert:" + r.TLS.VerifiedChains[0][0].Subject.CommonName, true
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		return "", false
+	}
+	if name, ok := operator(cfg.Tokens, token); ok {
+		return "token:" + name, true
+	}
+	return "", false
+}
+
+// bearerToken - The token of an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// operator - The name of the operator owning a token. Every token is compared, in constant time and
+// on their digests so that neither the position nor the length of a token leaks.
+func operator(tokens map[string]string, token string) (string, bool) {
+	digest := sha256.Sum256([]byte(token))
+	name, found := "", 0
+	for candidate, expected := range tokens {
+		expectedDigest := sha256.Sum256([]byte(expected))
+		match := subtle.ConstantTimeCompare(digest[:], expectedDigest[:])
+		if match == 1 {
+			name = candidate
+		}
+		found |= match
+	}
+	return name, found == 1
+}
+
+// adminActor - The operator authenticated by requireAdmin
+func adminActor(r *http.Request) string {
+	actor, _ := r.Context().Value(actorKey).(string)
+	return actor
+}
+
+// adminTLSConfig - Serve the admin endpoints over TLS, asking for a client certificate signed by the client CA
+func adminTLSConfig(cfg models.AdminConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", cfg.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return tlsConfig, nil
+}
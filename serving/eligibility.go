@@ -0,0 +1,81 @@
+package serving
+
+import (
+	"context"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// accountLock - Lock state of an account, UnlocksOn is set once notified for unlock
+type accountLock struct {
+	UnlocksOn *time.Time `json:"unlocksOn"`
+}
+
+// eligibility - Classify accounts according to an eligibility policy and the exclusion list
+type eligibility struct {
+	policy    models.EligibilityPolicy
+	addresses map[string]string
+	excluded  map[string]string
+}
+
+// newEligibility - Index the address lists of a policy by class
+func newEligibility(policy models.EligibilityPolicy, excluded map[string]string) *eligibility {
+	e := &eligibility{
+		policy:    policy,
+		addresses: map[string]string{},
+		excluded:  excluded,
+	}
+	// Lowest priority first so that a higher priority class wins
+	for _, list := range []struct {
+		class     string
+		addresses []string
+	}{
+		{models.AccountClassNode, policy.NodeAddresses},
+		{models.AccountClassExchange, policy.ExchangeAddresses},
+		{models.AccountClassSystem, policy.SystemAddresses},
+	} {
+		for _, address := range list.addresses {
+			e.addresses[address] = list.class
+		}
+	}
+	return e
+}
+
+// classify - Return the class of an account and whether the policy includes it
+func (e *eligibility) classify(address string, detail accountDetail) (class string, eligible bool) {
+	if _, ok := e.excluded[address]; ok {
+		return models.AccountClassExcluded, false
+	}
+
+	switch listed, ok := e.addresses[address]; {
+	case ok:
+		class = listed
+	case detail.Lock != nil && detail.Lock.UnlocksOn != nil:
+		class = models.AccountClassNotified
+	case detail.Lock != nil:
+		class = models.AccountClassLocked
+	default:
+		class = models.AccountClassRegular
+	}
+	return class, e.policy.Includes(class)
+}
+
+// exclusions - Merge the configured exclusion list with the exclusions table, by address
+func (k *KnClient) exclusions(ctx context.Context, cfg *models.Config, repo dal.Repo) (map[string]string, error) {
+	excluded := map[string]string{}
+	for _, exclusion := range cfg.Exclusions {
+		excluded[exclusion.Address] = exclusion.Reason
+	}
+
+	list, err := repo.ListExclusions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, exclusion := range list {
+		excluded[exclusion.Address] = exclusion.Reason
+	}
+
+	return excluded, nil
+}
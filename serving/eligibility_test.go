@@ -0,0 +1,102 @@
+package serving
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+func TestClassify(t *testing.T) {
+	unlocksOn := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	locked := accountDetail{Lock: &accountLock{}}
+	notified := accountDetail{Lock: &accountLock{UnlocksOn: &unlocksOn}}
+
+	policy := models.DefaultEligibilityPolicy()
+	policy.SystemAddresses = []string{"system", "everywhere"}
+	policy.ExchangeAddresses = []string{"exchange", "everywhere", "exchange-node"}
+	policy.NodeAddresses = []string{"node", "everywhere", "exchange-node"}
+
+	tests := []struct {
+		name    string
+		address string
+		detail  accountDetail
+		want    string
+	}{
+		{"regular", "regular", accountDetail{}, models.AccountClassRegular},
+		{"locked", "regular", locked, models.AccountClassLocked},
+		{"notified for unlock", "regular", notified, models.AccountClassNotified},
+		{"system", "system", accountDetail{}, models.AccountClassSystem},
+		{"exchange", "exchange", accountDetail{}, models.AccountClassExchange},
+		{"node", "node", accountDetail{}, models.AccountClassNode},
+		{"listed classes win over the lock", "node", locked, models.AccountClassNode},
+		{"system wins over the other lists", "everywhere", accountDetail{}, models.AccountClassSystem},
+		{"exchange wins over node", "exchange-node", accountDetail{}, models.AccountClassExchange},
+		{"excluded wins over everything", "excluded", notified, models.AccountClassExcluded},
+		{"an excluded listed account", "system-excluded", accountDetail{}, models.AccountClassExcluded},
+	}
+	policy.SystemAddresses = append(policy.SystemAddresses, "system-excluded")
+	e := newEligibility(policy, map[string]string{"excluded": "test", "system-excluded": "test"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, eligible := e.classify(tt.address, tt.detail)
+			if class != tt.want {
+				t.Errorf("classify(%s) class = %s, want %s", tt.address, class, tt.want)
+			}
+			if wantEligible := tt.want != models.AccountClassExcluded; eligible != wantEligible {
+				t.Errorf("classify(%s) eligible = %t, want %t with every class included", tt.address, eligible, wantEligible)
+			}
+		})
+	}
+}
+
+func TestClassifyFollowsThePolicy(t *testing.T) {
+	unlocksOn := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	accounts := []struct {
+		address string
+		detail  accountDetail
+		class   string
+	}{
+		{"system", accountDetail{}, models.AccountClassSystem},
+		{"exchange", accountDetail{}, models.AccountClassExchange},
+		{"node", accountDetail{}, models.AccountClassNode},
+		{"notified", accountDetail{Lock: &accountLock{UnlocksOn: &unlocksOn}}, models.AccountClassNotified},
+		{"locked", accountDetail{Lock: &accountLock{}}, models.AccountClassLocked},
+		{"regular", accountDetail{}, models.AccountClassRegular},
+	}
+
+	for _, excludedClass := range []string{
+		models.AccountClassSystem, models.AccountClassExchange, models.AccountClassNode,
+		models.AccountClassNotified, models.AccountClassLocked,
+	} {
+		t.Run("without "+excludedClass, func(t *testing.T) {
+			policy := models.DefaultEligibilityPolicy()
+			policy.SystemAddresses = []string{"system"}
+			policy.ExchangeAddresses = []string{"exchange"}
+			policy.NodeAddresses = []string{"node"}
+			switch excludedClass {
+			case models.AccountClassSystem:
+				policy.IncludeSystem = false
+			case models.AccountClassExchange:
+				policy.IncludeExchange = false
+			case models.AccountClassNode:
+				policy.IncludeNode = false
+			case models.AccountClassNotified:
+				policy.IncludeNotified = false
+			case models.AccountClassLocked:
+				policy.IncludeLocked = false
+			}
+
+			e := newEligibility(policy, nil)
+			for _, account := range accounts {
+				class, eligible := e.classify(account.address, account.detail)
+				if class != account.class {
+					t.Errorf("classify(%s) class = %s, want %s", account.address, class, account.class)
+				}
+				if want := class != excludedClass; eligible != want {
+					t.Errorf("classify(%s) eligible = %t, want %t", account.address, eligible, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package serving
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the allocation tests")
+
+const (
+	// mainnetAccounts - The number of accounts of the synthetic mainnet-sized chain
+	mainnetAccounts = 10000
+	// summaryTop - The accounts with the most effective votes listed by a summary golden
+	summaryTop = 20
+)
+
+// syntheticMainnet - A chain of mainnet size, always the same one: mostly small regular accounts,
+// a third of them unseated, some locked and a few whales
+func syntheticMainnet() *nodetest.Fixture {
+	r := rand.New(rand.NewSource(1))
+	genesis := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	fixture := &nodetest.Fixture{Accounts: map[string]nodetest.Account{}}
+	total := 0
+	for i := 0; i < mainnetAccounts; i++ {
+		account := nodetest.Account{Balance: int(r.ExpFloat64() * 5000)}
+		if r.Intn(100) < 2 {
+			account.Balance *= 1000
+		}
+		if account.Balance >= 1000 && r.Intn(3) > 0 {
+			account.CurrencySeatDate = genesis.AddDate(0, 0, r.Intn(6*365))
+		}
+		switch n := r.Intn(100); {
+		case n < 2:
+			unlocksOn := genesis.AddDate(7, 0, r.Intn(365))
+			account.Lock = &nodetest.Lock{UnlocksOn: &unlocksOn}
+		case n < 12:
+			account.Lock = &nodetest.Lock{}
+		}
+		fixture.Accounts[fmt.Sprintf("ndam%044d", r.Int63())] = account
+		total += account.Balance
+	}
+	// Part of the ndau on chain is not held by accounts
+	fixture.TotalNdau = total + total/10
+
+	return fixture
+}
+
+// loadFixture - A fixture of testdata/node
+func loadFixture(name string) func() (*nodetest.Fixture, error) {
+	return func() (*nodetest.Fixture, error) {
+		return nodetest.LoadFixture(filepath.Join("testdata", "node", name+".json"))
+	}
+}
+
+// goldenHeader - The columns of goldenAccounts
+const goldenHeader = "# address seat_date class eligible votes effective_votes"
+
+// goldenAccounts - The accounts table after a run, one account per line in address order
+func goldenAccounts(accounts []models.VotingSetup) []byte {
+	var out bytes.Buffer
+	fmt.Fprintln(&out, goldenHeader)
+	for _, account := range accounts {
+		fmt.Fprintf(&out, "%s %s %s %t %.6f %.6f\n",
+			account.Address,
+			account.CurrencySeatDate.UTC().Format("2006-01-02"),
+			account.EligibilityReason,
+			account.Eligible,
+			account.Votes,
+			account.EffectiveVotes,
+		)
+	}
+	return out.Bytes()
+}
+
+// goldenSummary - The accounts table of a large chain in a reviewable size: the counts and votes of each
+// class, the accounts with the most effective votes and the digest of the whole table, which any change
+// of the vote results alters
+func goldenSummary(accounts []models.VotingSetup) []byte {
+	type class struct {
+		accounts, eligible, seated int
+		votes, effective           float64
+	}
+	classes := map[string]*class{}
+	var votes, effective float64
+	for _, account := range accounts {
+		c := classes[account.EligibilityReason]
+		if c == nil {
+			c = &class{}
+			classes[account.EligibilityReason] = c
+		}
+		c.accounts++
+		if account.Eligible {
+			c.eligible++
+		}
+		if account.Seated() {
+			c.seated++
+		}
+		c.votes += account.Votes
+		c.effective += account.EffectiveVotes
+		votes += account.Votes
+		effective += account.EffectiveVotes
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "accounts %d\n", len(accounts))
+	fmt.Fprintf(&out, "votes %.6f\n", votes)
+	fmt.Fprintf(&out, "effective_votes %.6f\n", effective)
+
+	fmt.Fprintln(&out, "# class accounts eligible seated votes effective_votes")
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := classes[name]
+		fmt.Fprintf(&out, "%s %d %d %d %.6f %.6f\n", name, c.accounts, c.eligible, c.seated, c.votes, c.effective)
+	}
+
+	// Ties are broken by address, the accounts being in address order
+	top := append([]models.VotingSetup{}, accounts...)
+	sort.SliceStable(top, func(i, j int) bool { return top[i].EffectiveVotes > top[j].EffectiveVotes })
+	if len(top) > summaryTop {
+		top = top[:summaryTop]
+	}
+	fmt.Fprintf(&out, "# top %d by effective votes\n", summaryTop)
+	out.Write(bytes.TrimPrefix(goldenAccounts(top), []byte(goldenHeader+"\n")))
+
+	fmt.Fprintf(&out, "sha256 %x\n", sha256.Sum256(goldenAccounts(accounts)))
+	return out.Bytes()
+}
+
+// TestAllocationGolden - Run the whole pipeline on recorded chains and compare the accounts table with
+// testdata/golden. Run with -update to accept a change of the vote results.
+func TestAllocationGolden(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     func() (*nodetest.Fixture, error)
+		delegations [][2]string
+		// golden renders the accounts, goldenAccounts when nil
+		golden func([]models.VotingSetup) []byte
+	}{
+		{
+			name:    "small",
+			fixture: loadFixture("small"),
+			delegations: [][2]string{
+				{"ndaaregular0000000000000000000000000000000000002", "ndaaregular0000000000000000000000000000000000001"},
+			},
+		},
+		{name: "ties", fixture: loadFixture("ties")},
+		{name: "unseated", fixture: loadFixture("unseated")},
+		{
+			name:    "mainnet",
+			fixture: func() (*nodetest.Fixture, error) { return syntheticMainnet(), nil },
+			golden:  goldenSummary,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture, err := tt.fixture()
+			if err != nil {
+				t.Fatalf("failed loading the fixture: %v", err)
+			}
+			node := nodetest.NewServer(fixture)
+			defer node.Close()
+
+			k, repo, cfg := newPipeline(t, node)
+			cfg.Run.PageSize = 100
+			cfg.Run.BatchSize = 300
+			for _, d := range tt.delegations {
+				if err := repo.SetDelegation(runContext(), d[0], d[1]); err != nil {
+					t.Fatalf("SetDelegation() = %v", err)
+				}
+			}
+			if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err != nil {
+				t.Fatalf("ProcessEvent() = %v", err)
+			}
+			accounts, err := repo.ListAccount(runContext())
+			if err != nil {
+				t.Fatalf("ListAccount() = %v", err)
+			}
+			golden := goldenAccounts
+			if tt.golden != nil {
+				golden = tt.golden
+			}
+			got := golden(accounts)
+
+			path := filepath.Join("testdata", "golden", tt.name+".golden")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("failed writing %s: %v", path, err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed reading %s, run with -update to create it: %v", path, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("the accounts differ from %s, run with -update if the change is intended:\n%s", path, lineDiff(want, got))
+			}
+		})
+	}
+}
+
+// lineDiff - The first lines that differ, enough to locate a change of the vote results
+func lineDiff(want, got []byte) string {
+	const maxLines = 10
+
+	wantLines := bytes.Split(want, []byte("\n"))
+	gotLines := bytes.Split(got, []byte("\n"))
+	var out bytes.Buffer
+	shown := 0
+	for i := 0; (i < len(wantLines) || i < len(gotLines)) && shown < maxLines; i++ {
+		var w, g []byte
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if !bytes.Equal(w, g) {
+			fmt.Fprintf(&out, "line %d:\n- %s\n+ %s\n", i+1, w, g)
+			shown++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,91 @@
+package serving
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+const (
+	healthOK = "ok"
+
+	// nodeStatusAPI answers without touching the chain state
+	nodeStatusAPI = "/node/status"
+)
+
+// readiness - The outcome of each readiness check
+type readiness struct {
+	Status string
+	Checks map[string]string
+}
+
+// registerHealthRoutes - Probes for Kubernetes. /healthz only tells the process is alive,
+// /readyz also requires the database and, when configured, the node API to answer.
+func (k *KnClient) registerHealthRoutes(ctx context.Context, mux *http.ServeMux, repo dal.Repo, store *configuration.Store) {
+	mux.HandleFunc("/healthz", k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"Status": healthOK})
+	}))
+	mux.HandleFunc("/readyz", k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+		k.ready(ctx, w, r, repo, store.Load())
+	}))
+}
+
+// ready - GET /readyz
+func (k *KnClient) ready(server context.Context, w http.ResponseWriter, r *http.Request, repo dal.Repo, cfg *models.Config) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(cfg.Health.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	result := readiness{
+		Status: "ready",
+		Checks: map[string]string{},
+	}
+	fail := func(check string, err error) {
+		k.Log.Warnf("Readiness check '%s' failed: %v", check, err)
+		result.Status = "unavailable"
+		result.Checks[check] = err.Error()
+	}
+
+	// Stop receiving traffic as soon as the shutdown starts
+	if err := server.Err(); err != nil {
+		fail("server", err)
+	}
+
+	if err := repo.Ping(ctx); err != nil {
+		fail("database", err)
+	} else {
+		result.Checks["database"] = healthOK
+	}
+
+	if cfg.Health.CheckNode {
+		if err := k.pingNode(ctx, cfg); err != nil {
+			fail("node", err)
+		} else {
+			result.Checks["node"] = healthOK
+		}
+	}
+
+	status := http.StatusOK
+	if result.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, result)
+}
+
+// pingNode - Check that the configured node API answers
+func (k *KnClient) pingNode(ctx context.Context, cfg *models.Config) error {
+	if cfg.NodeAPI == "" {
+		return errNoNodeAPI
+	}
+
+	conn, err := k.checkClient(cfg.Network, cfg.NodeAPI)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.GetDataWithContext(ctx, nodeStatusAPI, nil)
+	return err
+}
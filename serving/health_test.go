@@ -0,0 +1,145 @@
+package serving
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+)
+
+// newHealthServer - The health routes of a server whose lifetime is ctx
+func newHealthServer(t *testing.T, ctx context.Context, repo dal.Repo, cfg *models.Config) *httptest.Server {
+	t.Helper()
+
+	k, err := NewKnClient(cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	mux := http.NewServeMux()
+	k.registerHealthRoutes(ctx, mux, repo, configuration.NewStore(cfg))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getReadiness - GET /readyz and decode the checks, which are answered with 503 as well
+func getReadiness(t *testing.T, server *httptest.Server) (int, readiness) {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("GET /readyz = %v", err)
+	}
+	defer resp.Body.Close()
+	var body readiness
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("GET /readyz: failed decoding the answer: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestHealthz(t *testing.T) {
+	cfg := models.DefaultConfig()
+	repo := dal.NewMemory()
+	repo.Close()
+	server := newHealthServer(t, context.Background(), repo, &cfg)
+
+	// Alive even without a database
+	var body map[string]string
+	if status := getJSON(t, server, "/healthz", &body); status != http.StatusOK || body["Status"] != healthOK {
+		t.Errorf("GET /healthz = %d %v, want %d", status, body, http.StatusOK)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	node := newFakeNode(t)
+	stopped, stop := context.WithCancel(context.Background())
+	stop()
+	closed := dal.NewMemory()
+	closed.Close()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		repo      dal.Repo
+		checkNode bool
+		faults    nodetest.Faults
+		want      int
+		failed    string
+	}{
+		{name: "ready", ctx: context.Background(), repo: dal.NewMemory(), want: http.StatusOK},
+		{name: "node ready", ctx: context.Background(), repo: dal.NewMemory(), checkNode: true, want: http.StatusOK},
+		{name: "database down", ctx: context.Background(), repo: closed, want: http.StatusServiceUnavailable, failed: "database"},
+		{name: "shutting down", ctx: stopped, repo: dal.NewMemory(), want: http.StatusServiceUnavailable, failed: "server"},
+		{
+			name: "node down", ctx: context.Background(), repo: dal.NewMemory(), checkNode: true,
+			faults: nodetest.Faults{Errors: map[string]int{nodetest.StatusAPI: http.StatusBadGateway}},
+			want:   http.StatusServiceUnavailable, failed: "node",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node.SetFaults(tt.faults)
+			cfg := models.DefaultConfig()
+			cfg.Network = "testnet"
+			cfg.NodeAPI = node.URL
+			cfg.Health.CheckNode = tt.checkNode
+			server := newHealthServer(t, tt.ctx, tt.repo, &cfg)
+
+			status, body := getReadiness(t, server)
+			if status != tt.want {
+				t.Errorf("GET /readyz = %d %+v, want %d", status, body, tt.want)
+			}
+			if tt.failed != "" && (body.Checks[tt.failed] == "" || body.Checks[tt.failed] == healthOK) {
+				t.Errorf("GET /readyz checks = %v, want '%s' failed", body.Checks, tt.failed)
+			}
+			if tt.want == http.StatusOK && body.Checks["database"] != healthOK {
+				t.Errorf("GET /readyz checks = %v, want the database ok", body.Checks)
+			}
+		})
+	}
+}
+
+// TestReadyzAnswersWithinItsTimeout - A node slower than health.timeout_seconds fails the check in time
+// for the probe, which waits one second longer
+func TestReadyzAnswersWithinItsTimeout(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{Latency: 5 * time.Second})
+	cfg := models.DefaultConfig()
+	cfg.Network = "testnet"
+	cfg.NodeAPI = node.URL
+	cfg.Health.CheckNode = true
+	cfg.Health.TimeoutSeconds = 1
+	server := newHealthServer(t, context.Background(), dal.NewMemory(), &cfg)
+
+	start := time.Now()
+	if status, body := getReadiness(t, server); status != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz = %d %+v, want %d", status, body, http.StatusServiceUnavailable)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Duration(cfg.Health.TimeoutSeconds+1)*time.Second {
+		t.Errorf("GET /readyz answered after %v, past the probe timeout", elapsed)
+	}
+}
+
+func TestHealthRoutesOnlyAnswerGet(t *testing.T) {
+	cfg := models.DefaultConfig()
+	server := newHealthServer(t, context.Background(), dal.NewMemory(), &cfg)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := http.Post(server.URL+path, "application/json", nil)
+		if err != nil {
+			t.Fatalf("POST %s = %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("POST %s = %d, want %d", path, resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	}
+}
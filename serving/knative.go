@@ -7,13 +7,18 @@ import (
 	"io/ioutil"
 	"net/http"
 	"sort"
+	"sync"
 	"time"
 
 	uuid "github.com/google/uuid"
+	"github.com/ndau/dao-voting-setup/configuration"
 	"github.com/ndau/dao-voting-setup/dal"
 	"github.com/ndau/dao-voting-setup/models"
 	logger "github.com/ndau/go-logger"
 	"github.com/ndau/go-ndau"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -24,6 +29,24 @@ const (
 type KnClient struct {
 	// Optional: logging
 	Log logger.Logger
+
+	// Verifier checks the signature of the votes
+	Verifier KeyVerifier
+
+	// NewNodeClient connects to the node APIs, the instrumented go-ndau client when nil
+	NewNodeClient NodeFactory
+
+	// runMu is held while a run is in progress
+	runMu sync.Mutex
+
+	// limiter caps the requests sent to the node
+	limiter *rateLimiter
+	// httpClient sends the requests to the node, its idle connections are closed by Stop
+	httpClient *http.Client
+
+	// lifeMu guards life, set by Start
+	lifeMu sync.Mutex
+	life   *lifecycle
 }
 
 // NewKnClient -
@@ -39,27 +62,42 @@ func NewKnClient(cfg *models.Config, loggers ...logger.Logger) (knc *KnClient, e
 	return &KnClient{
 		// Optional: logging
 		Log: log,
-	}, nil
-}
 
-// Run knative function
-func (k *KnClient) Run(ctx context.Context, repo dal.Repo, cfg *models.Config) error {
-	k.Log.Info("Starting knative run...")
-	k.Listen(ctx, repo, cfg)
-	return nil
-}
+		Verifier: Ed25519Verifier{},
 
-// Listen ...
-func (k *KnClient) Listen(ctx context.Context, repo dal.Repo, cfg *models.Config) {
-	trackingNumber := uuid.New().String()
+		limiter: newRateLimiter(cfg.Run.RequestsPerSecond),
+		httpClient: &http.Client{
+			Transport: http.DefaultTransport.(*http.Transport).Clone(),
+		},
+	}, nil
+}
 
+// routes - The PingSource, metrics, health and query endpoints, and the admin endpoints behind
+// authentication. Each request reads the current configuration of the store.
+func (k *KnClient) routes(ctx context.Context, repo dal.Repo, store *configuration.Store) (*http.ServeMux, http.Handler) {
 	handler := func(w http.ResponseWriter, r *http.Request) {
-		thisContext := context.WithValue(ctx, "tracking_number", trackingNumber)
+		// Only the PingSource path triggers runs, anything else unmatched is not found
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		trackingNumber := uuid.New().String()
+		requestCtx, cancel := requestContext(ctx, r)
+		defer cancel()
+		thisContext := context.WithValue(requestCtx, "tracking_number", trackingNumber)
+		trace.SpanFromContext(thisContext).SetAttributes(trackingNumberKey.String(trackingNumber))
 
 		k.Log.Infof("%s | Start processing knative request", trackingNumber)
 		fmt.Printf("%+v\n", r)
 		switch r.Method {
 		case "POST":
+			if !k.runMu.TryLock() {
+				k.Log.Warnf("%s | A run is already in progress, skipping", trackingNumber)
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			defer k.runMu.Unlock()
+
 			if body, err := ioutil.ReadAll(r.Body); err != nil {
 				k.Log.Errorf("%s | Failed to read request body", trackingNumber, err)
 			} else {
@@ -67,11 +105,11 @@ func (k *KnClient) Listen(ctx context.Context, repo dal.Repo, cfg *models.Config
 				if err := json.Unmarshal(body, &data); err != nil {
 					k.Log.Errorf("%s | Failed to unmarshal request data", trackingNumber, err)
 				} else {
-					err := k.ProcessEvent(thisContext, &data, repo, cfg)
+					err := k.ProcessEvent(thisContext, &data, repo, store.Load())
 					if err != nil {
 						k.Log.Errorf("%s | Failed to process the request: %v", trackingNumber, err)
 					} else {
-						k.Log.Infof("%s | Finish")
+						k.Log.Infof("%s | Finish", trackingNumber)
 					}
 				}
 			}
@@ -80,20 +118,56 @@ func (k *KnClient) Listen(ctx context.Context, repo dal.Repo, cfg *models.Config
 		}
 	}
 
-	port := "8080"
-	http.HandleFunc("/", handler)
-	if err := http.ListenAndServe(fmt.Sprintf(":%s", port), nil); err != nil {
-		k.Log.Errorf("%s | Failed to listening on the port %d: %v", trackingNumber, port, err)
+	cfg := store.Load()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handler)
+	mux.Handle("/metrics", promhttp.Handler())
+	k.registerHealthRoutes(ctx, mux, repo, store)
+	if cfg.Features.QueryAPI {
+		k.registerQueryRoutes(mux, repo, store)
 	}
 
-	k.Log.Infof("knative is listening on port %d", port)
+	// The PingSource path stays open, every admin endpoint requires authentication
+	adminMux := http.NewServeMux()
+	k.registerAdminRoutes(ctx, adminMux, repo, store)
+	admin := k.requireAdmin(cfg.Admin, adminMux)
+	if !cfg.Admin.TLS() {
+		mux.Handle("/admin/", admin)
+	}
 
+	return mux, admin
 }
 
 // ProcessEvent ...
-func (k *KnClient) ProcessEvent(ctx context.Context, data *models.Data, repo dal.Repo, cfg *models.Config) error {
+func (k *KnClient) ProcessEvent(ctx context.Context, data *models.Data, repo dal.Repo, cfg *models.Config) (err error) {
 	trackingNumber, _ := ctx.Value("tracking_number").(string)
 
+	ctx, span := startSpan(ctx, "ProcessEvent", attribute.String("network", data.Network), attribute.Int64("snapshot.id", data.SnapshotID))
+	defer func() {
+		endSpan(span, err)
+	}()
+	if id := traceID(ctx); id != "" {
+		k.Log.Infof("%s | Trace %s", trackingNumber, id)
+	}
+
+	// Rebuild the allocation of a past run from the database only
+	if data.SnapshotID != 0 {
+		_, err = k.Recompute(ctx, repo, cfg, data.SnapshotID, data.Policy)
+		return err
+	}
+
+	name := policyName(cfg, data.Policy)
+	policy, err := cfg.Policy(name)
+	if err != nil {
+		k.Log.Errorf("%s | %v", trackingNumber, err)
+		return err
+	}
+
+	report := models.RunReport{
+		TrackingNumber: trackingNumber,
+		StartedAt:      time.Now(),
+	}
+
 	k.Log.Infof("%s | Start processing event...", trackingNumber)
 	// The “currency seat date” is the date at which the account’s balance first reached 1,000 ndau
 	// after the most recent time it was below 1,000. If an account reached 1,000 ndau on 1/1/21 and
@@ -101,31 +175,74 @@ func (k *KnClient) ProcessEvent(ctx context.Context, data *models.Data, repo dal
 	// dropped to 999 ndau yesterday but came back above 1,000 today, its currency seat date is today.
 	// Accounts with fewer than 1,000 ndau in them have no currency seat date.
 
+	// Missing fields of the request come from the configuration
+	requested := data.NodeAPI
+	resolved := data.WithDefaults(cfg)
+	data = &resolved
+	if data.NodeAPI == "" {
+		err = errNoNodeAPI
+		k.Log.Errorf("%s | No node API requested nor configured", trackingNumber)
+		return err
+	}
+	if requested == "" {
+		data.NodeAPI = k.selectNode(ctx, cfg)
+	}
+
 	network := data.Network
 	baseURL := data.NodeAPI
 
 	k.Log.Infof("%s | Network/NodeAPI: %s/%s", trackingNumber, network, baseURL)
 
+	// Record the chain state read by this run, so that it can be recomputed later
+	snapshot := &models.Snapshot{
+		TrackingNumber: trackingNumber,
+		Policy:         name,
+		Status:         models.SnapshotRunning,
+		Network:        network,
+		StartedAt:      report.StartedAt,
+	}
+	if err = repo.CreateSnapshot(ctx, snapshot); err != nil {
+		k.Log.Errorf("%s | Failed to create the run snapshot. Error = %v", trackingNumber, err)
+		return err
+	}
+	defer func() {
+		k.finishSnapshot(ctx, repo, snapshot, err)
+		if err == nil {
+			k.pruneSnapshots(ctx, repo, cfg)
+		}
+	}()
+
 	// Create the NdauAPI client
-	defaultClient := http.DefaultClient
-	conn, err := ndau.New(defaultClient, &ndau.NdauConfig{
-		Network: network,
-		NodeAPI: baseURL,
-	}, k.Log)
+	conn, err := k.nodeClient(network, baseURL)
 	if err != nil {
 		k.Log.Errorf("%s | Failed to instantiate ndau client to the network %s. Error = %s", trackingNumber, network, err.Error())
 		return err
 	}
 
 	// Get non-duplicated account list
+	endPhase := observePhase(phaseCacheBuilder)
 	cache, err := k.cacheBuilder(ctx, data, repo, conn)
+	endPhase()
 	if err != nil {
 		k.Log.Errorf("%s | Failed to build existing accounts cache", trackingNumber)
 		return err
 	}
 
+	// The exclusions of the configuration and of the table, recorded with the snapshot
+	excluded, err := k.exclusions(ctx, cfg, repo)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to read the exclusion list. Error = %v", trackingNumber, err)
+		return err
+	}
+	if err = repo.SaveSnapshotExclusions(ctx, snapshot.ID, snapshotExclusions(excluded)); err != nil {
+		k.Log.Errorf("%s | Failed to save the exclusions of snapshot %d. Error: %v", trackingNumber, snapshot.ID, err)
+		return err
+	}
+
 	// Get account balances and currency seat dates
-	accountList, unseatList, total, err := k.watcher(ctx, data, cfg, cache, repo, conn)
+	endPhase = observePhase(phaseWatcher)
+	accountList, unseatList, total, err := k.watcher(ctx, data, cfg, cache, excluded, conn)
+	endPhase()
 	if err != nil {
 		k.Log.Errorf("%s | Failed to run diff with the account cache", trackingNumber)
 		return err
@@ -147,17 +264,28 @@ func (k *KnClient) ProcessEvent(ctx context.Context, data *models.Data, repo dal
 		}
 	}
 
+	summarize(&report, accountList, unseatList, total)
+
 	// Compute voting power for each seated account
-	if err = k.updateVote(ctx, accountList, unseatList, total, repo, conn); err != nil {
+	endPhase = observePhase(phaseUpdateVote)
+	voteErr := k.updateVote(ctx, cfg, accountList, unseatList, total, policy, snapshot, repo, conn, &report)
+	endPhase()
+	if voteErr != nil {
 		k.Log.Errorf("%s | Failed to update account votings", trackingNumber)
 	}
 
 	// Freeze concluded proposals
-	if proposals, err := repo.ListActiveProposal(); err != nil {
+	endPhase = observePhase(phaseConclusion)
+	if !cfg.Features.ConcludeProposals {
+		k.Log.Infof("%s | Concluding proposals is disabled", trackingNumber)
+	} else if proposals, err := repo.ListActiveProposal(ctx); err != nil {
 		k.Log.Warnf("%s | Failed to read proposals from database. Error: %v. Skip checking concluded polls", trackingNumber, err)
 	} else {
 		k.Log.Infof("%s | proposals %+v", trackingNumber, proposals)
 		for _, proposal := range proposals {
+			if ctx.Err() != nil {
+				break
+			}
 			today := time.Now()
 			closingDate := proposal.ClosingDate
 			if today.After(closingDate) {
@@ -166,17 +294,32 @@ func (k *KnClient) ProcessEvent(ctx context.Context, data *models.Data, repo dal
 				// Update concluded votes
 				if err := repo.UpdateConcludedVotes(ctx, proposalID); err != nil {
 					k.Log.Errorf("%s | Failed to update concluded votes. Will retry next day. Error: %v", trackingNumber, err)
+				} else {
+					proposalsConcluded.Inc()
 				}
 			}
 		}
 	}
+	endPhase()
+
+	if err := ctx.Err(); err != nil {
+		k.Log.Warnf("%s | Run cancelled: %v", trackingNumber, err)
+		return err
+	}
+
+	report.FinishedAt = time.Now()
+	observeReport(&report)
+	if out, err := json.Marshal(report); err == nil {
+		k.Log.Infof("%s | Run report: %s", trackingNumber, out)
+	}
 
 	k.Log.Infof("%s | Done", trackingNumber)
 
-	return nil
+	// Concluded proposals are frozen even when the votings failed, the run still fails
+	return voteErr
 }
 
-func (k *KnClient) cacheBuilder(ctx context.Context, data *models.Data, repo dal.Repo, conn *ndau.Ndau) (models.Cached, error) {
+func (k *KnClient) cacheBuilder(ctx context.Context, data *models.Data, repo dal.Repo, conn NodeClient) (models.Cached, error) {
 	trackingNumber, _ := ctx.Value("tracking_number").(string)
 
 	var params interface{}
@@ -187,7 +330,7 @@ func (k *KnClient) cacheBuilder(ctx context.Context, data *models.Data, repo dal
 
 	// Get the existing database first
 	// Update accounts that lost their seats
-	if accounts, err := repo.ListAccount(); err != nil {
+	if accounts, err := repo.ListAccount(ctx); err != nil {
 		k.Log.Warnf("%s | Failed to read accounts from database. Error: %v. Will try my best", trackingNumber, err)
 	} else {
 		for _, account := range accounts {
@@ -201,6 +344,10 @@ func (k *KnClient) cacheBuilder(ctx context.Context, data *models.Data, repo dal
 	after := data.StartAfterKey
 
 	for {
+		if err := ctx.Err(); err != nil {
+			k.Log.Warnf("%s | Stopped reading accounts after '%v': %v", trackingNumber, after, err)
+			return nil, err
+		}
 		if after == "" {
 			break
 		} else {
@@ -213,7 +360,9 @@ func (k *KnClient) cacheBuilder(ctx context.Context, data *models.Data, repo dal
 			json.Unmarshal(input, &params)
 		}
 		api := "/account/list"
-		res, err := conn.GetDataWithContext(ctx, api, params)
+		res, err := nodeCall(ctx, api, func(ctx context.Context) ([]byte, error) {
+			return conn.GetDataWithContext(ctx, api, params)
+		}, attribute.String("account.after", after), attribute.Int("account.limit", limit))
 		if err != nil {
 			k.Log.Errorf("%s | Failed to get accounts: %s", trackingNumber, err.Error())
 			return nil, err
@@ -246,12 +395,15 @@ func (k *KnClient) cacheBuilder(ctx context.Context, data *models.Data, repo dal
 	return cache, nil
 }
 
-func (k *KnClient) watcher(ctx context.Context, data *models.Data, cfg *models.Config, cache models.Cached, repo dal.Repo, conn *ndau.Ndau) (votingList []ndau.Account, unseatList models.Cached, totalNdau int, err error) {
+func (k *KnClient) watcher(ctx context.Context, data *models.Data, cfg *models.Config, cache models.Cached, excluded map[string]string, conn NodeClient) (votingList []models.ChainAccount, unseatList models.Cached, totalNdau int, err error) {
 	trackingNumber, _ := ctx.Value("tracking_number").(string)
 
 	var void struct{}
 	unseatList = models.Cached{}
 
+	k.Log.Infof("%s | Excluding %d listed addresses", trackingNumber, len(excluded))
+	policy := newEligibility(cfg.Eligibility, excluded)
+
 	count := 0
 	numberOfAccounts := len(cache)
 	addresses := []string{}
@@ -261,12 +413,12 @@ func (k *KnClient) watcher(ctx context.Context, data *models.Data, cfg *models.C
 		count++
 		numberOfAccounts--
 
-		if count == 300 || numberOfAccounts == 0 {
+		if count == cfg.Run.BatchSize || numberOfAccounts == 0 {
 			// Now sort the slice
 			sort.Strings(addresses)
 
 			// Update account balance
-			if accounts, unseats, total_balance, err := k.updateBalance(ctx, addresses, conn); err == nil {
+			if accounts, unseats, total_balance, err := k.updateBalance(ctx, addresses, policy, conn); err == nil {
 				totalNdau = totalNdau + total_balance
 				votingList = append(votingList, accounts...)
 
@@ -280,7 +432,10 @@ func (k *KnClient) watcher(ctx context.Context, data *models.Data, cfg *models.C
 			}
 
 			// Give mainnet node some break
-			time.Sleep(5 * time.Second)
+			if err := sleep(ctx, time.Duration(cfg.Run.BatchPauseMs)*time.Millisecond); err != nil {
+				k.Log.Warnf("%s | Stopped reading balances: %v", trackingNumber, err)
+				return nil, nil, 0, err
+			}
 
 			count = 0
 			addresses = []string{}
@@ -295,7 +450,7 @@ func (k *KnClient) watcher(ctx context.Context, data *models.Data, cfg *models.C
 	return votingList, unseatList, totalNdau, nil
 }
 
-func (k *KnClient) updateBalance(ctx context.Context, addresses []string, conn *ndau.Ndau) (accounts []ndau.Account, unseats []string, total_balance int, err error) {
+func (k *KnClient) updateBalance(ctx context.Context, addresses []string, policy *eligibility, conn NodeClient) (accounts []models.ChainAccount, unseats []string, total_balance int, err error) {
 	trackingNumber, _ := ctx.Value("tracking_number").(string)
 
 	total_balance = 0
@@ -306,27 +461,32 @@ func (k *KnClient) updateBalance(ctx context.Context, addresses []string, conn *
 	input, _ := json.Marshal(addresses)
 	json.Unmarshal(input, &params)
 	api := "/account/accounts"
-	res, err := conn.PostDataWithContext(ctx, api, params)
+	res, err := nodeCall(ctx, api, func(ctx context.Context) ([]byte, error) {
+		return conn.PostDataWithContext(ctx, api, params)
+	}, attribute.Int("account.count", len(addresses)))
 	if err != nil {
 		k.Log.Errorf("%s | Failed to get account voting list: %s", trackingNumber, err.Error())
 		return nil, nil, total_balance, err
 	}
 
-	var r ndau.AccountResp
+	var r map[string]accountDetail
 	if err = json.Unmarshal(res, &r); err != nil {
 		k.Log.Errorf("%s | Failed to unmarshall account detail response: %s", trackingNumber, err.Error())
 		return nil, nil, total_balance, err
 	}
 
 	for address, val := range r {
-		account := ndau.Account{
-			Id:               address,
+		class, eligible := policy.classify(address, val)
+		account := models.ChainAccount{
+			Address:          address,
 			CurrencySeatDate: val.CurrencySeatDate,
 			Balance:          val.Balance,
+			Class:            class,
+			Eligible:         eligible,
 		}
 		accounts = append(accounts, account)
 
-		if val.CurrencySeatDate.Year() < 2016 {
+		if val.CurrencySeatDate.Before(models.SeatedSince) {
 			unseats = append(unseats, address)
 		}
 
@@ -338,13 +498,15 @@ func (k *KnClient) updateBalance(ctx context.Context, addresses []string, conn *
 	return accounts, unseats, total_balance, nil
 }
 
-func (k *KnClient) updateVote(ctx context.Context, votingList []ndau.Account, unseatList models.Cached, total_balance int, repo dal.Repo, conn *ndau.Ndau) error {
+func (k *KnClient) updateVote(ctx context.Context, cfg *models.Config, votingList []models.ChainAccount, unseatList models.Cached, total_balance int, policy models.AllocationPolicy, snapshot *models.Snapshot, repo dal.Repo, conn NodeClient, report *models.RunReport) error {
 	trackingNumber, _ := ctx.Value("tracking_number").(string)
 
 	k.Log.Infof("%s | Get current price and total Ndau...", trackingNumber)
 
 	api := "/price/current"
-	res, err := conn.GetDataWithContext(ctx, api, nil)
+	res, err := nodeCall(ctx, api, func(ctx context.Context) ([]byte, error) {
+		return conn.GetDataWithContext(ctx, api, nil)
+	})
 	if err != nil {
 		k.Log.Errorf("%s | Failed to get total Ndau: %s", trackingNumber, err.Error())
 		return err
@@ -357,77 +519,55 @@ func (k *KnClient) updateVote(ctx context.Context, votingList []ndau.Account, un
 	}
 
 	k.Log.Infof("%s | Total Ndau = %d", trackingNumber, r.TotalNdau)
+	report.ChainTotalNdau = r.TotalNdau
+	if r.TotalNdau > 0 {
+		report.ExcludedShare = float64(report.ExcludedNdau) / float64(r.TotalNdau)
+	}
 	if total_balance != r.TotalNdau {
 		k.Log.Warnf("%s | Unmatched total Ndau: %d", trackingNumber, total_balance)
 	}
 
 	// Now let's compute the voting power for each seated account
+	votes := allocate(votingList, unseatList, r.TotalNdau, policy)
 
-	votes := []models.VotingSetup{}
-
-	// There are 9,000,000 votes in total
-	//   - One third of the votes are assigned equally to each currency seat.
-	//   - One third of the votesare assigned proportionally to each address based on its share of all ndau in circulation
-	//   - And the final third are assigned equally to each of the three oldest currency seat addresses
-	noOfAccount := len(votingList)
-	noOfCurrencySeat := noOfAccount - len(unseatList)
-	for i := 0; i < noOfAccount; i++ {
-		var power float64
-		address := votingList[i].Id
-		if _, unseated := unseatList[address]; unseated {
-			power = 3000000 * float64(votingList[i].Balance) / float64(r.TotalNdau)
-		} else {
-			power = 3000000 * (1.0/float64(noOfCurrencySeat) + float64(votingList[i].Balance)/float64(r.TotalNdau))
-		}
-
-		vote := models.VotingSetup{
-			Address:          address,
-			CurrencySeatDate: votingList[i].CurrencySeatDate,
-			Votes:            power,
-		}
-		votes = append(votes, vote)
-	}
-
-	// Find 3 oldest currency seats
-	oldestCurrencySeats := [...]int{0, 1, 2}
-	firstThree := 0
-	for i := 0; i < noOfAccount; i++ {
-		address := votingList[i].Id
-		if _, unseated := unseatList[address]; !unseated {
-			if firstThree < 3 {
-				oldestCurrencySeats[firstThree] = i
-				firstThree = firstThree + 1
-				k.Log.Infof("%s | i = %d, address = %s", trackingNumber, i, address)
-			} else {
-				current := 0
-				youngest := oldestCurrencySeats[0]
-				if votes[youngest].CurrencySeatDate.Before(votes[oldestCurrencySeats[1]].CurrencySeatDate) ||
-					votes[youngest].CurrencySeatDate.Before(votes[oldestCurrencySeats[2]].CurrencySeatDate) {
-					if votes[oldestCurrencySeats[1]].CurrencySeatDate.After(votes[oldestCurrencySeats[2]].CurrencySeatDate) {
-						current = 1
-						youngest = oldestCurrencySeats[1]
-					} else {
-						current = 2
-						youngest = oldestCurrencySeats[2]
-					}
-				}
-
-				if votes[youngest].CurrencySeatDate.After(votingList[i].CurrencySeatDate) {
-					oldestCurrencySeats[current] = i
-				}
-			}
-		}
+	// Hand the voting power of delegators over to their delegates
+	delegations, err := k.delegations(ctx, repo, cfg)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to read delegations: %v", trackingNumber, err)
+		return err
+	}
+	delegated := applyDelegations(votes, delegations, models.MaxDelegationDepth)
+	k.Log.Infof("%s | Resolved %d of %d delegations", trackingNumber, delegated, len(delegations))
+	if err := repo.SaveSnapshotDelegations(ctx, snapshot.ID, snapshotDelegations(delegations)); err != nil {
+		k.Log.Errorf("%s | Failed to save the delegations of snapshot %d. Error: %v", trackingNumber, snapshot.ID, err)
+		return err
 	}
 
-	votes[oldestCurrencySeats[0]].Votes = votes[oldestCurrencySeats[0]].Votes + 1000000
-	votes[oldestCurrencySeats[1]].Votes = votes[oldestCurrencySeats[1]].Votes + 1000000
-	votes[oldestCurrencySeats[2]].Votes = votes[oldestCurrencySeats[2]].Votes + 1000000
+	snapshot.ChainTotalNdau = r.TotalNdau
+	if err := repo.SaveSnapshotAccounts(ctx, snapshot.ID, snapshotAccounts(votingList, votes)); err != nil {
+		k.Log.Errorf("%s | Failed to save snapshot %d. Error: %v", trackingNumber, snapshot.ID, err)
+		return err
+	}
 
 	k.Log.Infof("%s | Start updating %d account votings...", trackingNumber, len(votingList))
 
 	if err := repo.UpsertVotingList(ctx, votes); err != nil {
 		k.Log.Errorf("%s | Failed to insert to send_file_log table. Error: %v", trackingNumber, err)
+		return err
 	}
 
 	return nil
 }
+
+// delegations - The delegations to apply, none when the feature is disabled
+func (k *KnClient) delegations(ctx context.Context, repo dal.Repo, cfg *models.Config) (models.Delegations, error) {
+	if !cfg.Features.Delegations {
+		return models.Delegations{}, nil
+	}
+
+	delegations, err := repo.ListDelegations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewDelegations(delegations), nil
+}
@@ -0,0 +1,189 @@
+package serving
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+var (
+	// ErrStarted is returned when starting a client that is already started
+	ErrStarted = errors.New("knative client is already started")
+	// ErrNotStarted is returned when stopping a client that was never started
+	ErrNotStarted = errors.New("knative client is not started")
+)
+
+// lifecycle - What Start brings up and Stop tears down
+type lifecycle struct {
+	// cancel stops the runs and requests in progress
+	cancel  context.CancelFunc
+	servers []*http.Server
+	// errs receives the error of a server that stopped on its own
+	errs    chan error
+	repo    dal.Repo
+	stopped bool
+	stopErr error
+}
+
+// Run knative function, it returns once the context is cancelled, or a server fails, and everything is shut down
+func (k *KnClient) Run(ctx context.Context, repo dal.Repo, store *configuration.Store) error {
+	k.Log.Info("Starting knative run...")
+	if err := k.Start(ctx, repo, store); err != nil {
+		return err
+	}
+
+	k.lifeMu.Lock()
+	errs := k.life.errs
+	k.lifeMu.Unlock()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+		k.Log.Errorf("A server stopped unexpectedly: %v", err)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if stopErr := k.Stop(stopCtx); err == nil {
+		err = stopErr
+	}
+	return err
+}
+
+// Start - Listen on the configured ports and serve in the background. Ports are bound before it
+// returns, so that a port already in use is reported here. The repository is owned by the client
+// from now on and closed by Stop.
+func (k *KnClient) Start(ctx context.Context, repo dal.Repo, store *configuration.Store) error {
+	k.lifeMu.Lock()
+	defer k.lifeMu.Unlock()
+	if k.life != nil {
+		return ErrStarted
+	}
+
+	cfg := store.Load()
+	store.OnChange(func(cfg *models.Config) {
+		k.limiter.setRate(cfg.Run.RequestsPerSecond)
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+	life := &lifecycle{cancel: cancel, repo: repo}
+	mux, admin := k.routes(runCtx, repo, store)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to listen on the port %d: %v", cfg.Port, err)
+	}
+	listeners := []net.Listener{listener}
+	life.servers = append(life.servers, &http.Server{Handler: traced(mux)})
+
+	if cfg.Admin.TLS() {
+		tlsListener, err := k.adminListener(cfg.Admin)
+		if err != nil {
+			listener.Close()
+			cancel()
+			return err
+		}
+		listeners = append(listeners, tlsListener)
+		life.servers = append(life.servers, &http.Server{Handler: traced(admin)})
+	}
+
+	life.errs = make(chan error, len(life.servers))
+	for i, server := range life.servers {
+		server.Addr = listeners[i].Addr().String()
+		k.Log.Infof("knative is listening on %s", server.Addr)
+		go func(server *http.Server, listener net.Listener) {
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				life.errs <- err
+			}
+		}(server, listeners[i])
+	}
+
+	k.life = life
+	return nil
+}
+
+// adminListener - The TLS listener of the admin endpoints
+func (k *KnClient) adminListener(cfg models.AdminConfig) (net.Listener, error) {
+	tlsConfig, err := adminTLSConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the admin TLS listener: %v", err)
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the admin certificate: %v", err)
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+
+	listener, err := tls.Listen("tcp", fmt.Sprintf(":%d", cfg.Port), tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on the admin port %d: %v", cfg.Port, err)
+	}
+	return listener, nil
+}
+
+// Stop - Shut down in order: stop accepting requests and cancel the runs in progress, wait for the
+// in-flight requests and the runs to record their outcome, then close the node client connections
+// and finally the repository. The context bounds the wait, the repository of a run still in progress
+// after it is not closed. Further calls return the first outcome.
+func (k *KnClient) Stop(ctx context.Context) error {
+	k.lifeMu.Lock()
+	defer k.lifeMu.Unlock()
+	life := k.life
+	if life == nil {
+		return ErrNotStarted
+	}
+	if life.stopped {
+		return life.stopErr
+	}
+	life.stopped = true
+
+	life.cancel()
+	for _, server := range life.servers {
+		k.Log.Infof("Shutting down the server on %s...", server.Addr)
+		if err := server.Shutdown(ctx); err != nil && life.stopErr == nil {
+			life.stopErr = err
+		}
+	}
+
+	// Runs triggered through the admin endpoints are not requests, wait for them to record their outcome
+	runs := make(chan struct{})
+	go func() {
+		k.runMu.Lock()
+		k.runMu.Unlock()
+		close(runs)
+	}()
+	stuck := false
+	select {
+	case <-runs:
+		k.Log.Info("knative server is shut down")
+	case <-ctx.Done():
+		k.Log.Errorf("Gave up waiting for the run in progress: %v", ctx.Err())
+		stuck = true
+		if life.stopErr == nil {
+			life.stopErr = ctx.Err()
+		}
+	}
+
+	k.httpClient.CloseIdleConnections()
+
+	// The stuck run still uses the repository, it is left open for the process exit to release
+	if stuck {
+		k.Log.Errorf("Leaving the database open for the run in progress")
+	} else if err := life.repo.Close(); err != nil {
+		k.Log.Errorf("Failed to close the database: %v", err)
+		if life.stopErr == nil {
+			life.stopErr = err
+		}
+	}
+
+	return life.stopErr
+}
@@ -0,0 +1,212 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal/mocks"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// newTestClient - A client listening on a free port, without the query API
+func newTestClient(t *testing.T) (*KnClient, *configuration.Store) {
+	t.Helper()
+
+	cfg := models.DefaultConfig()
+	cfg.Port = 0
+	cfg.Features.QueryAPI = false
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	return k, configuration.NewStore(&cfg)
+}
+
+// healthURL - The liveness probe of the main server of a started client
+func healthURL(t *testing.T, k *KnClient) string {
+	t.Helper()
+
+	_, port, err := net.SplitHostPort(k.life.servers[0].Addr)
+	if err != nil {
+		t.Fatalf("unexpected server address %s: %v", k.life.servers[0].Addr, err)
+	}
+	return "http://127.0.0.1:" + port + "/healthz"
+}
+
+func TestStartServesUntilStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+	repo.EXPECT().Close().Return(nil).Times(1)
+
+	k, store := newTestClient(t)
+	if err := k.Start(context.Background(), repo, store); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	url := healthURL(t, k)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET /healthz after Start() = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /healthz = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if err := k.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	if resp, err := http.Get(url); err == nil {
+		resp.Body.Close()
+		t.Errorf("GET /healthz after Stop() = %d, want a connection error", resp.StatusCode)
+	}
+}
+
+func TestStartTwice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+	repo.EXPECT().Close().Return(nil)
+
+	k, store := newTestClient(t)
+	if err := k.Start(context.Background(), repo, store); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	defer k.Stop(context.Background())
+
+	if err := k.Start(context.Background(), repo, store); !errors.Is(err, ErrStarted) {
+		t.Errorf("second Start() = %v, want %v", err, ErrStarted)
+	}
+}
+
+func TestStartOnAPortInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer busy.Close()
+
+	k, _ := newTestClient(t)
+	cfg := models.DefaultConfig()
+	cfg.Port = busy.Addr().(*net.TCPAddr).Port
+	if err := k.Start(context.Background(), repo, configuration.NewStore(&cfg)); err == nil {
+		t.Fatal("Start() on a port in use = nil, want an error")
+	}
+	if err := k.Stop(context.Background()); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Stop() after a failed Start() = %v, want %v", err, ErrNotStarted)
+	}
+}
+
+func TestStopBeforeStart(t *testing.T) {
+	k, _ := newTestClient(t)
+
+	if err := k.Stop(context.Background()); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Stop() = %v, want %v", err, ErrNotStarted)
+	}
+}
+
+func TestStopTwiceClosesTheRepositoryOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+	closeErr := errors.New("close failed")
+	repo.EXPECT().Close().Return(closeErr).Times(1)
+
+	k, store := newTestClient(t)
+	if err := k.Start(context.Background(), repo, store); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := k.Stop(context.Background()); !errors.Is(err, closeErr) {
+			t.Errorf("Stop() #%d = %v, want %v", i+1, err, closeErr)
+		}
+	}
+}
+
+func TestStopWaitsForTheRunBeforeClosingTheRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+	closed := make(chan struct{})
+	repo.EXPECT().Close().DoAndReturn(func() error {
+		close(closed)
+		return nil
+	})
+
+	k, store := newTestClient(t)
+	if err := k.Start(context.Background(), repo, store); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+
+	// A run in progress
+	k.runMu.Lock()
+	stopped := make(chan error)
+	go func() {
+		stopped <- k.Stop(context.Background())
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("the repository was closed while a run was in progress")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	k.runMu.Unlock()
+	if err := <-stopped; err != nil {
+		t.Errorf("Stop() = %v", err)
+	}
+	select {
+	case <-closed:
+	default:
+		t.Error("the repository was not closed")
+	}
+}
+
+func TestStopGivesUpOnAStuckRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	// The stuck run still uses the repository, which is not closed under it
+	repo := mocks.NewMockRepo(ctrl)
+
+	k, store := newTestClient(t)
+	if err := k.Start(context.Background(), repo, store); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+
+	k.runMu.Lock()
+	defer k.runMu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := k.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRunStopsWhenTheContextIsCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+	repo.EXPECT().Close().Return(nil)
+
+	k, store := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- k.Run(ctx, repo, store)
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return once the context was cancelled")
+	}
+}
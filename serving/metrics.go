@@ -0,0 +1,175 @@
+package serving
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+	"github.com/ndau/go-ndau"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "dao_voting"
+
+var (
+	runPhaseDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "run_phase_duration_seconds",
+		Help:      "Duration of each phase of a run.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 14),
+	}, []string{"phase"})
+
+	nodeRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "node_requests_total",
+		Help:      "Requests sent to the node API by endpoint and status code.",
+	}, []string{"endpoint", "status"})
+
+	nodeRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "node_request_duration_seconds",
+		Help:      "Latency of the node API by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	runAccounts = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "run_accounts",
+		Help:      "Accounts of the last run: scanned, seated, unseated and excluded.",
+	}, []string{"state"})
+
+	runNdau = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "run_ndau",
+		Help:      "Ndau of the last run: scanned from the accounts, on chain and excluded.",
+	}, []string{"source"})
+
+	proposalsConcluded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "proposals_concluded_total",
+		Help:      "Proposals whose votes were frozen.",
+	})
+)
+
+// Run phases
+const (
+	phaseCacheBuilder = "cache_builder"
+	phaseWatcher      = "watcher"
+	phaseUpdateVote   = "update_vote"
+	phaseConclusion   = "conclusion"
+)
+
+// observePhase - Time a phase of a run, call the returned function when it ends
+func observePhase(phase string) func() {
+	start := time.Now()
+	return func() {
+		runPhaseDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+	}
+}
+
+// observeReport - Publish the counts of a run report
+func observeReport(report *models.RunReport) {
+	runAccounts.WithLabelValues("scanned").Set(float64(report.Accounts))
+	runAccounts.WithLabelValues("seated").Set(float64(report.SeatedAccounts))
+	runAccounts.WithLabelValues("unseated").Set(float64(report.Accounts - report.SeatedAccounts - report.ExcludedAccounts))
+	runAccounts.WithLabelValues("excluded").Set(float64(report.ExcludedAccounts))
+	runNdau.WithLabelValues("scanned").Set(float64(report.ScannedNdau))
+	runNdau.WithLabelValues("chain").Set(float64(report.ChainTotalNdau))
+	runNdau.WithLabelValues("excluded").Set(float64(report.ExcludedNdau))
+}
+
+// instrumentedClient - Count and time the requests sent to the node API, within the rate limit
+type instrumentedClient struct {
+	next ndau.HttpClient
+	// limiter is nil for the checks, which are not rate limited
+	limiter *rateLimiter
+	// ctx is attached to the requests, go-ndau builds them without the context of the call
+	ctx context.Context
+}
+
+// Do -
+func (c instrumentedClient) Do(req *http.Request) (*http.Response, error) {
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+	if c.limiter != nil {
+		if err := c.limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := nodeEndpoint(req.URL.Path)
+	start := time.Now()
+	resp, err := c.next.Do(req)
+	nodeRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	nodeRequests.WithLabelValues(endpoint, status).Inc()
+
+	return resp, err
+}
+
+// nodeEndpoint - The API of a request path without its arguments, e.g. /account/account
+// for /account/account/{address}, to keep the label cardinality low
+func nodeEndpoint(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// contextNode - A go-ndau client per call, so that a cancelled run or an expired check stops its request
+type contextNode struct {
+	config *ndau.NdauConfig
+	client instrumentedClient
+	log    logger.Logger
+}
+
+// conn - The go-ndau client of a call
+func (c contextNode) conn(ctx context.Context) *ndau.Ndau {
+	client := c.client
+	client.ctx = ctx
+	return &ndau.Ndau{Config: c.config, Client: client, Log: c.log}
+}
+
+// GetDataWithContext -
+func (c contextNode) GetDataWithContext(ctx context.Context, api string, params interface{}) ([]byte, error) {
+	return c.conn(ctx).GetDataWithContext(ctx, api, params)
+}
+
+// PostDataWithContext -
+func (c contextNode) PostDataWithContext(ctx context.Context, api string, params interface{}) ([]byte, error) {
+	return c.conn(ctx).PostDataWithContext(ctx, api, params)
+}
+
+// nodeClient - A node API client within the rate limit, whose requests are instrumented unless
+// NewNodeClient replaces it
+func (k *KnClient) nodeClient(network, nodeAPI string) (NodeClient, error) {
+	return k.newNodeClient(network, nodeAPI, k.limiter)
+}
+
+// checkClient - A node API client outside of the rate limit, so that the readiness and failover checks do
+// not queue behind the requests of a run
+func (k *KnClient) checkClient(network, nodeAPI string) (NodeClient, error) {
+	return k.newNodeClient(network, nodeAPI, nil)
+}
+
+func (k *KnClient) newNodeClient(network, nodeAPI string, limiter *rateLimiter) (NodeClient, error) {
+	if k.NewNodeClient != nil {
+		return k.NewNodeClient(network, nodeAPI)
+	}
+	return contextNode{
+		config: &ndau.NdauConfig{Network: network, NodeAPI: nodeAPI},
+		client: instrumentedClient{next: k.httpClient, limiter: limiter},
+		log:    k.Log,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package serving
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNodeEndpoint(t *testing.T) {
+	for path, want := range map[string]string{
+		"/account/account/ndaaregular0000000000000000000000000000000000001": "/account/account",
+		"/account/list":  "/account/list",
+		"/price/current": "/price/current",
+		"/node/status":   "/node/status",
+		"/":              "/",
+	} {
+		if got := nodeEndpoint(path); got != want {
+			t.Errorf("nodeEndpoint(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestObserveReport(t *testing.T) {
+	observeReport(&models.RunReport{
+		Accounts:         10,
+		SeatedAccounts:   6,
+		ExcludedAccounts: 1,
+		ScannedNdau:      1000,
+		ChainTotalNdau:   1200,
+		ExcludedNdau:     100,
+	})
+
+	for state, want := range map[string]float64{"scanned": 10, "seated": 6, "unseated": 3, "excluded": 1} {
+		if got := testutil.ToFloat64(runAccounts.WithLabelValues(state)); got != want {
+			t.Errorf("run_accounts{state=%s} = %v, want %v", state, got, want)
+		}
+	}
+	for source, want := range map[string]float64{"scanned": 1000, "chain": 1200, "excluded": 100} {
+		if got := testutil.ToFloat64(runNdau.WithLabelValues(source)); got != want {
+			t.Errorf("run_ndau{source=%s} = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestRunMetrics(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{Errors: map[string]int{nodetest.PriceAPI: http.StatusServiceUnavailable}, FailFirst: 1})
+	k, repo, cfg := newPipeline(t, node)
+
+	list := nodeRequests.WithLabelValues(nodetest.ListAPI, "200")
+	failed := nodeRequests.WithLabelValues(nodetest.PriceAPI, "503")
+	listBefore, failedBefore := testutil.ToFloat64(list), testutil.ToFloat64(failed)
+	phases := testutil.CollectAndCount(runPhaseDuration)
+
+	k.ProcessEvent(runContext(), &models.Data{}, repo, cfg)
+
+	if got := testutil.ToFloat64(list) - listBefore; got != 3 {
+		t.Errorf("node_requests_total{endpoint=/account/list,status=200} grew by %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(failed) - failedBefore; got != 1 {
+		t.Errorf("node_requests_total{endpoint=/price/current,status=503} grew by %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(runPhaseDuration); got < phases || got < 3 {
+		t.Errorf("run_phase_duration_seconds has %d phases, want at least the cache builder, watcher and update vote", got)
+	}
+
+	// The metrics are exposed under their prefix
+	server := httptest.NewServer(promhttp.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET /metrics = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, name := range []string{
+		"dao_voting_node_requests_total",
+		"dao_voting_node_request_duration_seconds",
+		"dao_voting_run_phase_duration_seconds",
+		"dao_voting_run_accounts",
+	} {
+		if !strings.Contains(string(body), name) {
+			t.Errorf("GET /metrics does not expose %s", name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package serving
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// NodeClient - The requests sent to a node API, implemented by the go-ndau client
+type NodeClient interface {
+	GetDataWithContext(ctx context.Context, api string, params interface{}) ([]byte, error)
+	PostDataWithContext(ctx context.Context, api string, params interface{}) ([]byte, error)
+}
+
+// NodeFactory - Connect to the node API of a network
+type NodeFactory func(network, nodeAPI string) (NodeClient, error)
+
+// selectNode - The first configured node answering, the first one when none does
+func (k *KnClient) selectNode(ctx context.Context, cfg *models.Config) string {
+	trackingNumber, _ := ctx.Value("tracking_number").(string)
+
+	if len(cfg.Nodes) < 2 {
+		return cfg.NodeAPI
+	}
+	for _, node := range cfg.Nodes {
+		conn, err := k.checkClient(cfg.Network, node)
+		if err != nil {
+			continue
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Health.TimeoutSeconds)*time.Second)
+		_, err = conn.GetDataWithContext(pingCtx, nodeStatusAPI, nil)
+		cancel()
+		if err == nil {
+			return node
+		}
+		k.Log.Warnf("%s | Node %s does not answer: %v", trackingNumber, node, err)
+	}
+
+	k.Log.Warnf("%s | No configured node answers, using %s", trackingNumber, cfg.Nodes[0])
+	return cfg.Nodes[0]
+}
+
+// rateLimiter - Space the requests sent to the node evenly, a zero interval never waits
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter - A limiter of perSecond requests, unlimited when zero
+func newRateLimiter(perSecond float64) *rateLimiter {
+	r := &rateLimiter{}
+	r.setRate(perSecond)
+	return r
+}
+
+// setRate - Change the rate, e.g. when the configuration is reloaded
+func (r *rateLimiter) setRate(perSecond float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if perSecond <= 0 {
+		r.interval = 0
+		return
+	}
+	r.interval = time.Duration(float64(time.Second) / perSecond)
+}
+
+// wait - Block until the next request may be sent, or the context is cancelled
+func (r *rateLimiter) wait(ctx context.Context) error {
+	r.mu.Lock()
+	if r.interval == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	delay := r.next.Sub(now)
+	r.next = r.next.Add(r.interval)
+	r.mu.Unlock()
+
+	return sleep(ctx, delay)
+}
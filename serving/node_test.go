@@ -0,0 +1,158 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+)
+
+func TestSelectNode(t *testing.T) {
+	up := newFakeNode(t)
+	down := newFakeNode(t)
+	down.SetFaults(nodetest.Faults{Errors: map[string]int{nodetest.StatusAPI: http.StatusBadGateway}})
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+
+	tests := []struct {
+		name  string
+		nodes []string
+		want  string
+	}{
+		{"first answers", []string{up.URL, down.URL}, up.URL},
+		{"first fails", []string{down.URL, up.URL}, up.URL},
+		{"first unreachable", []string{gone.URL, down.URL, up.URL}, up.URL},
+		{"none answers", []string{down.URL, gone.URL}, down.URL},
+		// A single node is not checked
+		{"single node", []string{gone.URL}, gone.URL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := models.DefaultConfig()
+			cfg.Network = "testnet"
+			cfg.NodeAPI = tt.nodes[0]
+			cfg.Nodes = tt.nodes
+			k, err := NewKnClient(&cfg)
+			if err != nil {
+				t.Fatalf("NewKnClient() = %v", err)
+			}
+
+			if got := k.selectNode(runContext(), &cfg); got != tt.want {
+				t.Errorf("selectNode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	// elapsed - The time taken by n requests
+	elapsed := func(r *rateLimiter, n int) time.Duration {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := r.wait(context.Background()); err != nil {
+				t.Fatalf("wait() = %v", err)
+			}
+		}
+		return time.Since(start)
+	}
+
+	unlimited := newRateLimiter(0)
+	if got := elapsed(unlimited, 100); got > 50*time.Millisecond {
+		t.Errorf("100 unlimited requests took %v", got)
+	}
+
+	// The first request goes at once, the next ones are spaced by 20ms
+	limited := newRateLimiter(50)
+	if got := elapsed(limited, 6); got < 100*time.Millisecond {
+		t.Errorf("6 requests at 50 per second took %v, want at least 100ms", got)
+	}
+
+	// A reloaded rate applies to the next requests
+	limited.setRate(0)
+	if got := elapsed(limited, 100); got > 50*time.Millisecond {
+		t.Errorf("100 requests after lifting the limit took %v", got)
+	}
+}
+
+func TestRateLimiterStopsWithItsContext(t *testing.T) {
+	// One request every 10 seconds, the second one is throttled
+	limiter := newRateLimiter(0.1)
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatalf("wait() = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if err := limiter.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wait() returned %v after the cancellation", elapsed)
+	}
+}
+
+func TestThrottledNodeRequestStopsWithItsContext(t *testing.T) {
+	node := newFakeNode(t)
+	cfg := models.DefaultConfig()
+	cfg.Network = "testnet"
+	cfg.NodeAPI = node.URL
+	cfg.Run.RequestsPerSecond = 0.1
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	conn, err := k.nodeClient(cfg.Network, cfg.NodeAPI)
+	if err != nil {
+		t.Fatalf("nodeClient() = %v", err)
+	}
+	if _, err := conn.GetDataWithContext(runContext(), nodeStatusAPI, nil); err != nil {
+		t.Fatalf("GetDataWithContext() = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(runContext(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := conn.GetDataWithContext(ctx, nodeStatusAPI, nil); err == nil {
+		t.Error("GetDataWithContext() = nil, want the error of the expired context")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the throttled request returned %v after its deadline", elapsed)
+	}
+}
+
+func TestChecksSkipTheRateLimit(t *testing.T) {
+	up := newFakeNode(t)
+	down := newFakeNode(t)
+	down.SetFaults(nodetest.Faults{Errors: map[string]int{nodetest.StatusAPI: http.StatusBadGateway}})
+	cfg := models.DefaultConfig()
+	cfg.Network = "testnet"
+	cfg.NodeAPI = down.URL
+	cfg.Nodes = []string{down.URL, up.URL}
+	cfg.Run.RequestsPerSecond = 0.1
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	// A run holds the next slot, 10 seconds away
+	if err := k.limiter.wait(context.Background()); err != nil {
+		t.Fatalf("wait() = %v", err)
+	}
+
+	start := time.Now()
+	if got := k.selectNode(runContext(), &cfg); got != up.URL {
+		t.Errorf("selectNode() = %s, want %s", got, up.URL)
+	}
+	cfg.NodeAPI = up.URL
+	if err := k.pingNode(runContext(), &cfg); err != nil {
+		t.Errorf("pingNode() = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the checks took %v, want them outside of the rate limit", elapsed)
+	}
+}
@@ -0,0 +1,246 @@
+// Package nodetest is a fake ndau node API serving fixtures, so that runs can be tested end to end
+// offline. Faults such as latency, errors and inconsistent totals may be injected.
+package nodetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ListAPI pages through the addresses
+	ListAPI = "/account/list"
+	// AccountsAPI reads the details of a batch of accounts
+	AccountsAPI = "/account/accounts"
+	// AccountAPI reads the details of the account whose address follows
+	AccountAPI = "/account/account/"
+	// PriceAPI reads the total ndau on chain
+	PriceAPI = "/price/current"
+	// StatusAPI answers the health checks
+	StatusAPI = "/node/status"
+
+	defaultPageSize = 100
+)
+
+// Lock - The lock of an account, UnlocksOn is set once notified for unlock
+type Lock struct {
+	UnlocksOn *time.Time `json:"unlocksOn"`
+}
+
+// Account - The details of an account, as answered by /account/accounts
+type Account struct {
+	Balance          int       `json:"balance"`
+	CurrencySeatDate time.Time `json:"currencySeatDate"`
+	ValidationKeys   []string  `json:"validationKeys,omitempty"`
+	Lock             *Lock     `json:"lock,omitempty"`
+}
+
+// Fixture - The chain state served by the fake node
+type Fixture struct {
+	// Accounts by address, listed in address order by /account/list
+	Accounts map[string]Account `json:"accounts"`
+	// TotalNdau is answered by /price/current, the sum of the balances when zero
+	TotalNdau int `json:"totalNdau"`
+}
+
+// LoadFixture - Read a fixture from a JSON file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{}
+	if err := json.Unmarshal(data, fixture); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// Total - The ndau on chain answered by /price/current
+func (f *Fixture) Total() int {
+	if f.TotalNdau != 0 {
+		return f.TotalNdau
+	}
+	total := 0
+	for _, account := range f.Accounts {
+		total += account.Balance
+	}
+	return total
+}
+
+// Faults - The misbehaviours of the fake node
+type Faults struct {
+	// Latency delays every answer
+	Latency time.Duration
+	// Errors answers the APIs with a status code, e.g. {AccountsAPI: 503}
+	Errors map[string]int
+	// FailFirst limits the errors to the first requests of each API, all of them when zero
+	FailFirst int
+	// TotalNdauDelta is added to the total of /price/current, so that it does not match the balances
+	TotalNdauDelta int
+	// NumAccountsDelta is added to the count of /account/list
+	NumAccountsDelta int
+}
+
+// Server - A fake node API on a local port
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	fixture   *Fixture
+	addresses []string
+	faults    Faults
+	requests  map[string]int
+}
+
+// NewServer - Start a fake node serving a fixture, close it when done
+func NewServer(fixture *Fixture) *Server {
+	s := &Server{
+		fixture:  fixture,
+		requests: map[string]int{},
+	}
+	for address := range fixture.Accounts {
+		s.addresses = append(s.addresses, address)
+	}
+	sort.Strings(s.addresses)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ListAPI, s.list)
+	mux.HandleFunc(AccountsAPI, s.accounts)
+	mux.HandleFunc(AccountAPI, s.account)
+	mux.HandleFunc(PriceAPI, s.price)
+	mux.HandleFunc(StatusAPI, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{})
+	})
+	s.Server = httptest.NewServer(s.fault(mux))
+
+	return s
+}
+
+// SetFaults - Replace the faults of the next requests
+func (s *Server) SetFaults(faults Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = faults
+}
+
+// Requests - The number of requests received by an API
+func (s *Server) Requests(api string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[api]
+}
+
+// fault - Count the requests, then delay or fail them according to the faults
+func (s *Server) fault(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		count := s.requests[r.URL.Path]
+		faults := s.faults
+		s.mu.Unlock()
+
+		if faults.Latency > 0 {
+			select {
+			case <-time.After(faults.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if status, ok := faults.Errors[r.URL.Path]; ok && (faults.FailFirst == 0 || count <= faults.FailFirst) {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// list - A page of the addresses after the "after" one, "-" being before all of them
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	after := r.URL.Query().Get("after")
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	start := sort.SearchStrings(s.addresses, after)
+	if start < len(s.addresses) && s.addresses[start] == after {
+		start++
+	}
+	end := start + limit
+	if end > len(s.addresses) {
+		end = len(s.addresses)
+	}
+	page := append([]string{}, s.addresses[start:end]...)
+	nextAfter := ""
+	if end < len(s.addresses) {
+		nextAfter = page[len(page)-1]
+	}
+
+	s.mu.Lock()
+	delta := s.faults.NumAccountsDelta
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"NumAccounts": len(s.addresses) + delta,
+		"FirstIndex":  start,
+		"After":       after,
+		"NextAfter":   nextAfter,
+		"Accounts":    page,
+	})
+}
+
+// accounts - The details of the posted addresses, the unknown ones are left out
+func (s *Server) accounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	var addresses []string
+	if err := json.NewDecoder(r.Body).Decode(&addresses); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	details := map[string]Account{}
+	for _, address := range addresses {
+		if account, ok := s.fixture.Accounts[address]; ok {
+			details[address] = account
+		}
+	}
+	writeJSON(w, details)
+}
+
+// account - The details of a single account, left out when unknown
+func (s *Server) account(w http.ResponseWriter, r *http.Request) {
+	address := strings.TrimPrefix(r.URL.Path, AccountAPI)
+
+	details := map[string]Account{}
+	if account, ok := s.fixture.Accounts[address]; ok {
+		details[address] = account
+	}
+	writeJSON(w, details)
+}
+
+// price - The current price, only the total ndau is meaningful
+func (s *Server) price(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delta := s.faults.TotalNdauDelta
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"marketPrice": 0,
+		"targetPrice": 0,
+		"totalNdau":   s.fixture.Total() + delta,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
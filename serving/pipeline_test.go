@@ -0,0 +1,209 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+)
+
+const excludedAddress = "ndaaexcluded000000000000000000000000000000000005"
+
+// newFakeNode - A fake node serving the small fixture, closed at the end of the test
+func newFakeNode(t *testing.T) *nodetest.Server {
+	t.Helper()
+
+	fixture, err := nodetest.LoadFixture("testdata/node/small.json")
+	if err != nil {
+		t.Fatalf("LoadFixture() = %v", err)
+	}
+	node := nodetest.NewServer(fixture)
+	t.Cleanup(node.Close)
+	return node
+}
+
+// newPipeline - A client reading the fake node in pages of 3 and batches of 2, and an empty repository
+func newPipeline(t *testing.T, node *nodetest.Server) (*KnClient, *dal.Memory, *models.Config) {
+	t.Helper()
+
+	cfg := models.DefaultConfig()
+	cfg.Network = "testnet"
+	cfg.NodeAPI = node.URL
+	cfg.Run.PageSize = 3
+	cfg.Run.BatchSize = 2
+	cfg.Run.BatchPauseMs = 0
+	cfg.Exclusions = []models.Exclusion{{Address: excludedAddress, Reason: "test"}}
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	return k, dal.NewMemory(), &cfg
+}
+
+func runContext() context.Context {
+	return context.WithValue(context.Background(), "tracking_number", "pipeline")
+}
+
+// lastSnapshot - The snapshot of the latest run
+func lastSnapshot(t *testing.T, repo dal.Repo, id int64) *models.Snapshot {
+	t.Helper()
+
+	snapshot, err := repo.GetSnapshot(runContext(), id)
+	if err != nil {
+		t.Fatalf("GetSnapshot(%d) = %v", id, err)
+	}
+	return snapshot
+}
+
+func totalVotes(t *testing.T, repo dal.Repo) float64 {
+	t.Helper()
+
+	accounts, err := repo.ListAccount(runContext())
+	if err != nil {
+		t.Fatalf("ListAccount() = %v", err)
+	}
+	total := 0.0
+	for _, account := range accounts {
+		total += account.Votes
+	}
+	return total
+}
+
+func TestProcessEventAgainstAFakeNode(t *testing.T) {
+	node := newFakeNode(t)
+	k, repo, cfg := newPipeline(t, node)
+
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err != nil {
+		t.Fatalf("ProcessEvent() = %v", err)
+	}
+
+	// 7 accounts in pages of 3 and batches of 2
+	for api, want := range map[string]int{nodetest.ListAPI: 3, nodetest.AccountsAPI: 4, nodetest.PriceAPI: 1} {
+		if got := node.Requests(api); got != want {
+			t.Errorf("%s requests = %d, want %d", api, got, want)
+		}
+	}
+
+	accounts, err := repo.ListAccount(runContext())
+	if err != nil {
+		t.Fatalf("ListAccount() = %v", err)
+	}
+	if len(accounts) != 7 {
+		t.Fatalf("ListAccount() = %d accounts, want 7", len(accounts))
+	}
+	for _, account := range accounts {
+		if account.Address == excludedAddress && (account.Votes != 0 || account.EligibilityReason != models.AccountClassExcluded) {
+			t.Errorf("excluded account = %+v, want no vote", account)
+		}
+	}
+	if _, seated, err := repo.ListSeatedAccounts(runContext(), models.AccountQuery{}); err != nil || seated != 5 {
+		t.Errorf("ListSeatedAccounts() = %d, %v, want 5 seats", seated, err)
+	}
+
+	// All the seat and seniority votes, and the balance votes of the eligible 27000 of 30000 ndau
+	policy, _ := cfg.Policy(cfg.DefaultPolicy)
+	want := policy.SeatVotes + policy.BalanceVotes*27000/30000 + policy.SeniorityVotes
+	if got := totalVotes(t, repo); math.Abs(got-want) > 1e-6 {
+		t.Errorf("total votes = %v, want %v", got, want)
+	}
+
+	snapshot := lastSnapshot(t, repo, 1)
+	if snapshot.Status != models.SnapshotCompleted || snapshot.ChainTotalNdau != 30000 || snapshot.Network != "testnet" {
+		t.Errorf("snapshot = %+v, want a completed snapshot of 30000 ndau", snapshot)
+	}
+	if rows, err := repo.ListSnapshotAccounts(runContext(), snapshot.ID); err != nil || len(rows) != 7 {
+		t.Errorf("ListSnapshotAccounts() = %d rows, %v, want 7", len(rows), err)
+	}
+}
+
+func TestProcessEventWhenTheNodeFails(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{Errors: map[string]int{nodetest.AccountsAPI: http.StatusServiceUnavailable}})
+	k, repo, cfg := newPipeline(t, node)
+
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err == nil {
+		t.Fatal("ProcessEvent() = nil, want the node error")
+	}
+	if snapshot := lastSnapshot(t, repo, 1); snapshot.Status != models.SnapshotFailed {
+		t.Errorf("snapshot status = %s, want %s", snapshot.Status, models.SnapshotFailed)
+	}
+	if accounts, _ := repo.ListAccount(runContext()); len(accounts) != 0 {
+		t.Errorf("ListAccount() = %d accounts, want none written", len(accounts))
+	}
+}
+
+func TestProcessEventAfterATransientNodeError(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{Errors: map[string]int{nodetest.PriceAPI: http.StatusBadGateway}, FailFirst: 1})
+	k, repo, cfg := newPipeline(t, node)
+
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err == nil {
+		t.Fatal("first ProcessEvent() = nil, want the node error")
+	}
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err != nil {
+		t.Fatalf("second ProcessEvent() = %v", err)
+	}
+	if snapshot := lastSnapshot(t, repo, 2); snapshot.Status != models.SnapshotCompleted {
+		t.Errorf("snapshot status = %s, want %s", snapshot.Status, models.SnapshotCompleted)
+	}
+}
+
+func TestProcessEventWithInconsistentTotals(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{TotalNdauDelta: 10000, NumAccountsDelta: 3})
+	k, repo, cfg := newPipeline(t, node)
+
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err != nil {
+		t.Fatalf("ProcessEvent() = %v", err)
+	}
+
+	// The balance votes are shared against the total on chain, not the sum of the balances
+	policy, _ := cfg.Policy(cfg.DefaultPolicy)
+	want := policy.SeatVotes + policy.BalanceVotes*27000/40000 + policy.SeniorityVotes
+	if got := totalVotes(t, repo); math.Abs(got-want) > 1e-6 {
+		t.Errorf("total votes = %v, want %v", got, want)
+	}
+	if snapshot := lastSnapshot(t, repo, 1); snapshot.ChainTotalNdau != 40000 {
+		t.Errorf("snapshot total = %d, want 40000", snapshot.ChainTotalNdau)
+	}
+}
+
+func TestProcessEventOnASlowNode(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{Latency: 200 * time.Millisecond})
+	k, repo, cfg := newPipeline(t, node)
+
+	ctx, cancel := context.WithTimeout(runContext(), 50*time.Millisecond)
+	defer cancel()
+	if err := k.ProcessEvent(ctx, &models.Data{}, repo, cfg); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ProcessEvent() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := node.Requests(nodetest.AccountsAPI); got != 0 {
+		t.Errorf("%s requests = %d, want none after the deadline", nodetest.AccountsAPI, got)
+	}
+	if snapshot := lastSnapshot(t, repo, 1); snapshot.Status != models.SnapshotCancelled {
+		t.Errorf("snapshot status = %s, want %s", snapshot.Status, models.SnapshotCancelled)
+	}
+}
+
+func TestProcessEventWithAnInjectedNodeClient(t *testing.T) {
+	node := newFakeNode(t)
+	k, repo, cfg := newPipeline(t, node)
+	unreachable := errors.New("unreachable")
+	k.NewNodeClient = func(network, nodeAPI string) (NodeClient, error) {
+		return nil, unreachable
+	}
+
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); !errors.Is(err, unreachable) {
+		t.Errorf("ProcessEvent() = %v, want %v", err, unreachable)
+	}
+	if got := node.Requests(nodetest.ListAPI); got != 0 {
+		t.Errorf("%s requests = %d, want none", nodetest.ListAPI, got)
+	}
+}
@@ -0,0 +1,267 @@
+package serving
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+const (
+	defaultTopHolders = 10
+	maxTopHolders     = 100
+)
+
+// registerQueryRoutes - Read-only endpoints over the accounts and proposals tables
+func (k *KnClient) registerQueryRoutes(mux *http.ServeMux, repo dal.Repo, store *configuration.Store) {
+	mux.HandleFunc("/accounts", k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+		k.listAccounts(w, r, repo)
+	}))
+	mux.HandleFunc("/accounts/", k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+		k.getAccount(w, r, repo)
+	}))
+	mux.HandleFunc("/proposals", k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+		k.listProposals(w, r, repo)
+	}))
+	mux.HandleFunc("/proposals/", func(w http.ResponseWriter, r *http.Request) {
+		k.proposalRoutes(w, r, repo, store.Load())
+	})
+	mux.HandleFunc("/stats", k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+		k.getStats(w, r, repo)
+	}))
+}
+
+// listAccounts - GET /accounts?limit=&offset=&sort=votes|seat_date&order=asc|desc
+func (k *KnClient) listAccounts(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	q := r.URL.Query()
+
+	limit, err := intParam(q.Get("limit"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	offset, err := intParam(q.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	query := models.AccountQuery{
+		Limit:  limit,
+		Offset: offset,
+		SortBy: models.SortByVotes,
+		Desc:   true,
+	}
+	switch sort := q.Get("sort"); sort {
+	case "", models.SortByVotes:
+	case models.SortBySeatDate:
+		// Oldest seats first unless asked otherwise
+		query.SortBy = models.SortBySeatDate
+		query.Desc = false
+	default:
+		writeError(w, http.StatusBadRequest, "sort must be 'votes' or 'seat_date'")
+		return
+	}
+	switch order := q.Get("order"); order {
+	case "":
+	case "asc":
+		query.Desc = false
+	case "desc":
+		query.Desc = true
+	default:
+		writeError(w, http.StatusBadRequest, "order must be 'asc' or 'desc'")
+		return
+	}
+
+	accounts, total, err := repo.ListSeatedAccounts(r.Context(), query)
+	if err != nil {
+		k.Log.Errorf("Failed to list seated accounts: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list accounts")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.AccountPage{
+		Total:    total,
+		Limit:    query.PageLimit(),
+		Offset:   offset,
+		Accounts: accounts,
+	})
+}
+
+// getAccount - GET /accounts/{address}
+func (k *KnClient) getAccount(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	address := strings.TrimPrefix(r.URL.Path, "/accounts/")
+	if address == "" || strings.Contains(address, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	account, err := repo.GetAccount(r.Context(), address)
+	if errors.Is(err, dal.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	} else if err != nil {
+		k.Log.Errorf("Failed to read account %s: %v", address, err)
+		writeError(w, http.StatusInternalServerError, "failed to read account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// listProposals - GET /proposals?status=pending|open|concluded
+func (k *KnClient) listProposals(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.ProposalStatusPending, models.ProposalStatusOpen, models.ProposalStatusConcluded:
+	default:
+		writeError(w, http.StatusBadRequest, "status must be 'pending', 'open' or 'concluded'")
+		return
+	}
+
+	proposals, err := repo.ListProposals(r.Context(), status)
+	if err != nil {
+		k.Log.Errorf("Failed to list proposals: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list proposals")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, proposals)
+}
+
+// proposalRoutes - Dispatch /proposals/{id}/...
+func (k *KnClient) proposalRoutes(w http.ResponseWriter, r *http.Request, repo dal.Repo, cfg *models.Config) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/proposals/"), "/")
+	proposalID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "tally":
+		k.getOnly(func(w http.ResponseWriter, r *http.Request) {
+			k.getTally(w, r, repo, proposalID)
+		})(w, r)
+	case len(parts) == 2 && parts[1] == "votes":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "only POST method is supported")
+			return
+		}
+		k.castVote(w, r, repo, cfg, proposalID)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// getTally - GET /proposals/{id}/tally
+func (k *KnClient) getTally(w http.ResponseWriter, r *http.Request, repo dal.Repo, proposalID int64) {
+	ctx := r.Context()
+
+	proposal, err := repo.GetProposal(ctx, proposalID)
+	if errors.Is(err, dal.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "proposal not found")
+		return
+	} else if err != nil {
+		k.Log.Errorf("Failed to read proposal %d: %v", proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to read proposal")
+		return
+	}
+
+	total, err := repo.TotalVotingPower(ctx)
+	if err != nil {
+		k.Log.Errorf("Failed to read the total voting power: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to tally proposal")
+		return
+	}
+
+	live, err := repo.LiveTally(ctx, proposalID)
+	if err != nil {
+		k.Log.Errorf("Failed to tally live votes of proposal %d: %v", proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to tally proposal")
+		return
+	}
+
+	frozen, err := repo.ConcludedTally(ctx, proposalID)
+	if err != nil {
+		k.Log.Errorf("Failed to tally concluded votes of proposal %d: %v", proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to tally proposal")
+		return
+	}
+
+	// The live turnout is relative to the current total voting power, the frozen one to the total
+	// recorded with the frozen votes so that it does not change with later runs
+	if total > 0 {
+		live.Turnout = live.Cast() / total
+	}
+	frozenTotal := 0.0
+	if proposal.ConcludedTotalPower != nil {
+		frozenTotal = *proposal.ConcludedTotalPower
+	}
+	if frozenTotal > 0 {
+		frozen.Turnout = frozen.Cast() / frozenTotal
+	}
+
+	writeJSON(w, http.StatusOK, models.ProposalTally{
+		ProposalID:             proposalID,
+		Status:                 proposal.Status(time.Now()),
+		TotalVotingPower:       total,
+		Live:                   *live,
+		Frozen:                 *frozen,
+		FrozenTotalVotingPower: frozenTotal,
+	})
+}
+
+// getStats - GET /stats?top=
+func (k *KnClient) getStats(w http.ResponseWriter, r *http.Request, repo dal.Repo) {
+	top, err := intParam(r.URL.Query().Get("top"), defaultTopHolders)
+	if err != nil || top < 0 || top > maxTopHolders {
+		writeError(w, http.StatusBadRequest, "invalid top")
+		return
+	}
+
+	stats, err := repo.GetStats(r.Context(), top)
+	if err != nil {
+		k.Log.Errorf("Failed to aggregate stats: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to aggregate stats")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// getOnly - Reject anything but GET requests
+func (k *KnClient) getOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, "only GET method is supported")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func intParam(val string, def int) (int, error) {
+	if val == "" {
+		return def, nil
+	}
+	return strconv.Atoi(val)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"Error": msg})
+}
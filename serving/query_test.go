@@ -0,0 +1,215 @@
+package serving
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// newQueryServer - The query routes over an in-memory repository
+func newQueryServer(t *testing.T) (*httptest.Server, *dal.Memory) {
+	t.Helper()
+
+	cfg := models.DefaultConfig()
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+	repo := dal.NewMemory()
+	mux := http.NewServeMux()
+	k.registerQueryRoutes(mux, repo, configuration.NewStore(&cfg))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+// seatedAccount - An eligible account seated on the given day of 2020
+func seatedAccount(address string, votes float64, day int) models.VotingSetup {
+	return models.VotingSetup{
+		Address:           address,
+		CurrencySeatDate:  time.Date(2020, time.January, day, 0, 0, 0, 0, time.UTC),
+		Votes:             votes,
+		EffectiveVotes:    votes,
+		Eligible:          true,
+		EligibilityReason: models.AccountClassRegular,
+	}
+}
+
+// getJSON - GET a path and decode the answer, returning its status
+func getJSON(t *testing.T, server *httptest.Server, path string, v interface{}) int {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s = %v", path, err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s: failed decoding the answer: %v", path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestListAccounts(t *testing.T) {
+	server, repo := newQueryServer(t)
+	excluded := seatedAccount("x", 100, 1)
+	excluded.Eligible = false
+	repo.UpsertVotingList(runContext(), []models.VotingSetup{
+		seatedAccount("a", 10, 3), seatedAccount("b", 30, 2), seatedAccount("c", 20, 1), excluded,
+	})
+
+	tests := []struct {
+		path      string
+		want      []string
+		wantLimit int
+	}{
+		{"/accounts", []string{"b", "c", "a"}, models.DefaultAccountPage},
+		{"/accounts?limit=2", []string{"b", "c"}, 2},
+		{"/accounts?limit=2&offset=2", []string{"a"}, 2},
+		{"/accounts?limit=5000", []string{"b", "c", "a"}, models.MaxAccountPage},
+		{"/accounts?order=asc", []string{"a", "c", "b"}, models.DefaultAccountPage},
+		{"/accounts?sort=seat_date", []string{"c", "b", "a"}, models.DefaultAccountPage},
+		{"/accounts?sort=seat_date&order=desc", []string{"a", "b", "c"}, models.DefaultAccountPage},
+	}
+	for _, tt := range tests {
+		var page models.AccountPage
+		if status := getJSON(t, server, tt.path, &page); status != http.StatusOK {
+			t.Fatalf("GET %s = %d", tt.path, status)
+		}
+		got := []string{}
+		for _, account := range page.Accounts {
+			got = append(got, account.Address)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s = %v, want %v", tt.path, got, tt.want)
+		}
+		if page.Total != 3 || page.Limit != tt.wantLimit {
+			t.Errorf("GET %s = total %d and limit %d, want 3 and %d", tt.path, page.Total, page.Limit, tt.wantLimit)
+		}
+	}
+}
+
+func TestQueryRejectsBadParameters(t *testing.T) {
+	server, _ := newQueryServer(t)
+
+	for _, path := range []string{
+		"/accounts?limit=x",
+		"/accounts?offset=-1",
+		"/accounts?sort=balance",
+		"/accounts?order=up",
+		"/proposals?status=closed",
+		"/stats?top=-1",
+		"/stats?top=101",
+	} {
+		if status := getJSON(t, server, path, nil); status != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want %d", path, status, http.StatusBadRequest)
+		}
+	}
+	for _, path := range []string{"/accounts/missing", "/accounts/a/b", "/proposals/x/tally", "/proposals/1/other"} {
+		if status := getJSON(t, server, path, nil); status != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want %d", path, status, http.StatusNotFound)
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/accounts", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST /accounts = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodGet {
+		t.Errorf("POST /accounts = %d allowing %q, want %d allowing GET", resp.StatusCode, resp.Header.Get("Allow"), http.StatusMethodNotAllowed)
+	}
+}
+
+func TestGetAccount(t *testing.T) {
+	server, repo := newQueryServer(t)
+	repo.UpsertVotingList(runContext(), []models.VotingSetup{seatedAccount("a", 10, 3)})
+
+	var account models.VotingSetup
+	if status := getJSON(t, server, "/accounts/a", &account); status != http.StatusOK {
+		t.Fatalf("GET /accounts/a = %d", status)
+	}
+	if account.Address != "a" || account.Votes != 10 {
+		t.Errorf("GET /accounts/a = %+v", account)
+	}
+}
+
+func TestGetStatsListsSeatedTopHolders(t *testing.T) {
+	server, repo := newQueryServer(t)
+	excluded := seatedAccount("x", 100, 1)
+	excluded.Eligible = false
+	repo.UpsertVotingList(runContext(), []models.VotingSetup{seatedAccount("a", 10, 3), seatedAccount("b", 30, 2), excluded})
+
+	var stats models.Stats
+	if status := getJSON(t, server, "/stats?top=1", &stats); status != http.StatusOK {
+		t.Fatalf("GET /stats = %d", status)
+	}
+	if stats.SeatCount != 2 || stats.TotalVotes != 140 || len(stats.TopHolders) != 1 || stats.TopHolders[0].Address != "b" {
+		t.Errorf("GET /stats?top=1 = %+v, want 2 seats, 140 votes and b on top", stats)
+	}
+}
+
+func TestTallyKeepsTheFrozenTurnout(t *testing.T) {
+	server, repo := newQueryServer(t)
+	ctx := runContext()
+	proposal := models.Proposal{IsApproved: true, ClosingDate: time.Now().Add(-time.Hour)}
+	repo.CreateProposal(ctx, &proposal)
+	repo.UpsertVotingList(ctx, []models.VotingSetup{seatedAccount("a", 30, 1), seatedAccount("b", 10, 2), seatedAccount("c", 60, 3)})
+	repo.CastVote(ctx, &models.Vote{ProposalID: proposal.ProposalID, UserAddress: "a", Choice: models.ChoiceYes})
+	repo.CastVote(ctx, &models.Vote{ProposalID: proposal.ProposalID, UserAddress: "b", Choice: models.ChoiceNo})
+	if err := repo.UpdateConcludedVotes(ctx, proposal.ProposalID); err != nil {
+		t.Fatalf("UpdateConcludedVotes() = %v", err)
+	}
+
+	// A later run changes the voting power, the frozen turnout stays relative to the total when frozen
+	repo.UpsertVotingList(ctx, []models.VotingSetup{seatedAccount("c", 160, 3)})
+
+	var tally models.ProposalTally
+	path := fmt.Sprintf("/proposals/%d/tally", proposal.ProposalID)
+	if status := getJSON(t, server, path, &tally); status != http.StatusOK {
+		t.Fatalf("GET %s = %d", path, status)
+	}
+	if tally.Status != models.ProposalStatusConcluded || tally.TotalVotingPower != 200 || tally.FrozenTotalVotingPower != 100 {
+		t.Errorf("GET %s = %+v, want a concluded proposal with totals 200 and 100", path, tally)
+	}
+	if tally.Frozen.Yes != 30 || tally.Frozen.No != 10 || tally.Frozen.Turnout != 0.4 {
+		t.Errorf("frozen tally = %+v, want 30 yes, 10 no and a 0.4 turnout", tally.Frozen)
+	}
+	if tally.Live.Turnout != 0.2 {
+		t.Errorf("live turnout = %v, want 0.2", tally.Live.Turnout)
+	}
+}
+
+func TestTallyOfAnOpenProposal(t *testing.T) {
+	server, repo := newQueryServer(t)
+	ctx := runContext()
+	proposal := models.Proposal{IsApproved: true, ClosingDate: time.Now().Add(time.Hour)}
+	repo.CreateProposal(ctx, &proposal)
+	repo.UpsertVotingList(ctx, []models.VotingSetup{seatedAccount("a", 30, 1), seatedAccount("b", 10, 2)})
+	repo.CastVote(ctx, &models.Vote{ProposalID: proposal.ProposalID, UserAddress: "a", Choice: models.ChoiceAbstain})
+
+	var tally models.ProposalTally
+	path := fmt.Sprintf("/proposals/%d/tally", proposal.ProposalID)
+	if status := getJSON(t, server, path, &tally); status != http.StatusOK {
+		t.Fatalf("GET %s = %d", path, status)
+	}
+	if tally.Status != models.ProposalStatusOpen || tally.Live.Abstain != 30 || tally.Live.Turnout != 0.75 {
+		t.Errorf("GET %s = %+v, want an open proposal with 30 abstentions and a 0.75 turnout", path, tally)
+	}
+	if tally.Frozen != (models.Tally{}) || tally.FrozenTotalVotingPower != 0 {
+		t.Errorf("frozen tally of an open proposal = %+v and %v, want none", tally.Frozen, tally.FrozenTotalVotingPower)
+	}
+	if status := getJSON(t, server, "/proposals/999/tally", nil); status != http.StatusNotFound {
+		t.Errorf("GET the tally of a missing proposal = %d, want %d", status, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,172 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// ErrSnapshotIncomplete - Only the snapshots of completed runs hold every account
+var ErrSnapshotIncomplete = errors.New("snapshot is not completed")
+
+// policyName - The requested allocation policy, or the configured default
+func policyName(cfg *models.Config, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if cfg.DefaultPolicy != "" {
+		return cfg.DefaultPolicy
+	}
+	return models.DefaultPolicyName
+}
+
+// finishSnapshot - Record the outcome of a run, a run that returned an error failed unless it was cancelled
+func (k *KnClient) finishSnapshot(ctx context.Context, repo dal.Repo, snapshot *models.Snapshot, err error) {
+	trackingNumber, _ := ctx.Value("tracking_number").(string)
+
+	switch {
+	case cancelled(ctx, err):
+		snapshot.Status = models.SnapshotCancelled
+	case err != nil:
+		snapshot.Status = models.SnapshotFailed
+	case snapshot.Status == models.SnapshotRunning:
+		snapshot.Status = models.SnapshotCompleted
+	}
+
+	// A cancelled context cannot write anymore
+	ctx, cancel := detached(ctx)
+	defer cancel()
+
+	if err := repo.FinishSnapshot(ctx, snapshot); err != nil {
+		k.Log.Errorf("%s | Failed to finish snapshot %d. Error = %v", trackingNumber, snapshot.ID, err)
+	}
+}
+
+// pruneSnapshots - Delete the snapshots older than the retention, a failure only delays it to the next run
+func (k *KnClient) pruneSnapshots(ctx context.Context, repo dal.Repo, cfg *models.Config) {
+	trackingNumber, _ := ctx.Value("tracking_number").(string)
+
+	if cfg.Run.SnapshotRetentionDays <= 0 {
+		return
+	}
+	ctx, cancel := detached(ctx)
+	defer cancel()
+
+	before := time.Now().AddDate(0, 0, -cfg.Run.SnapshotRetentionDays)
+	deleted, err := repo.DeleteSnapshots(ctx, before)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to delete the snapshots finished or left running before %s. Error = %v", trackingNumber, before.Format(time.RFC3339), err)
+		return
+	}
+	k.Log.Infof("%s | Deleted %d snapshots finished or left running before %s", trackingNumber, deleted, before.Format(time.RFC3339))
+}
+
+// snapshotDelegations - The delegations to record with a snapshot
+func snapshotDelegations(delegations models.Delegations) []models.SnapshotDelegation {
+	rows := make([]models.SnapshotDelegation, 0, len(delegations))
+	for delegator, delegate := range delegations {
+		rows = append(rows, models.SnapshotDelegation{Delegator: delegator, Delegate: delegate})
+	}
+	return rows
+}
+
+// storedDelegations - The delegations recorded with a snapshot
+func storedDelegations(rows []models.SnapshotDelegation) models.Delegations {
+	delegations := models.Delegations{}
+	for _, row := range rows {
+		delegations[row.Delegator] = row.Delegate
+	}
+	return delegations
+}
+
+// snapshotExclusions - The exclusions to record with a snapshot
+func snapshotExclusions(excluded map[string]string) []models.SnapshotExclusion {
+	rows := make([]models.SnapshotExclusion, 0, len(excluded))
+	for address, reason := range excluded {
+		rows = append(rows, models.SnapshotExclusion{Address: address, Reason: reason})
+	}
+	return rows
+}
+
+// Recompute - Rerun the allocation on the accounts of a completed snapshot with a chosen policy,
+// without reading the chain. The result is stored as a new snapshot derived from the original one,
+// the voting list of the accounts table is left untouched. The delegations and exclusions are the ones
+// recorded with the original snapshot, later changes of their tables do not alter the result.
+func (k *KnClient) Recompute(ctx context.Context, repo dal.Repo, cfg *models.Config, snapshotId int64, policy string) (*models.Snapshot, error) {
+	trackingNumber, _ := ctx.Value("tracking_number").(string)
+
+	name := policyName(cfg, policy)
+	allocation, err := cfg.Policy(name)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := repo.GetSnapshot(ctx, snapshotId)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to read snapshot %d. Error = %v", trackingNumber, snapshotId, err)
+		return nil, err
+	}
+	if parent.Status != models.SnapshotCompleted {
+		k.Log.Errorf("%s | Snapshot %d is %s, only completed snapshots can be recomputed", trackingNumber, snapshotId, parent.Status)
+		return nil, ErrSnapshotIncomplete
+	}
+
+	rows, err := repo.ListSnapshotAccounts(ctx, snapshotId)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to read accounts of snapshot %d. Error = %v", trackingNumber, snapshotId, err)
+		return nil, err
+	}
+	delegations, err := repo.ListSnapshotDelegations(ctx, snapshotId)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to read delegations of snapshot %d. Error = %v", trackingNumber, snapshotId, err)
+		return nil, err
+	}
+	exclusions, err := repo.ListSnapshotExclusions(ctx, snapshotId)
+	if err != nil {
+		k.Log.Errorf("%s | Failed to read exclusions of snapshot %d. Error = %v", trackingNumber, snapshotId, err)
+		return nil, err
+	}
+	k.Log.Infof("%s | Recomputing %d accounts of snapshot %d with the %s policy", trackingNumber, len(rows), snapshotId, name)
+
+	snapshot := &models.Snapshot{
+		TrackingNumber: trackingNumber,
+		ParentID:       &parent.ID,
+		Policy:         name,
+		Status:         models.SnapshotRunning,
+		Network:        parent.Network,
+		ChainTotalNdau: parent.ChainTotalNdau,
+		StartedAt:      time.Now(),
+	}
+	if err = repo.CreateSnapshot(ctx, snapshot); err != nil {
+		k.Log.Errorf("%s | Failed to create the derived snapshot. Error = %v", trackingNumber, err)
+		return nil, err
+	}
+	defer func() {
+		k.finishSnapshot(ctx, repo, snapshot, err)
+	}()
+
+	// The accounts keep the eligibility decided by the original run, its exclusions are only carried over
+	accounts := chainAccounts(rows)
+	votes := allocate(accounts, unseated(accounts), parent.ChainTotalNdau, allocation)
+	applyDelegations(votes, storedDelegations(delegations), models.MaxDelegationDepth)
+
+	if err = repo.SaveSnapshotDelegations(ctx, snapshot.ID, delegations); err != nil {
+		k.Log.Errorf("%s | Failed to save the delegations of snapshot %d. Error: %v", trackingNumber, snapshot.ID, err)
+		return nil, err
+	}
+	if err = repo.SaveSnapshotExclusions(ctx, snapshot.ID, exclusions); err != nil {
+		k.Log.Errorf("%s | Failed to save the exclusions of snapshot %d. Error: %v", trackingNumber, snapshot.ID, err)
+		return nil, err
+	}
+	if err = repo.SaveSnapshotAccounts(ctx, snapshot.ID, snapshotAccounts(accounts, votes)); err != nil {
+		k.Log.Errorf("%s | Failed to save snapshot %d. Error: %v", trackingNumber, snapshot.ID, err)
+		return nil, err
+	}
+
+	k.Log.Infof("%s | Snapshot %d recomputed as snapshot %d", trackingNumber, snapshotId, snapshot.ID)
+
+	return snapshot, nil
+}
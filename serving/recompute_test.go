@@ -0,0 +1,121 @@
+package serving
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ndau/dao-voting-setup/dal/mocks"
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+)
+
+const (
+	delegatorAddress = "ndaaregular0000000000000000000000000000000000001"
+	delegateAddress  = "ndaaregular0000000000000000000000000000000000002"
+	laterAddress     = "ndaaregular0000000000000000000000000000000000007"
+)
+
+func TestRecomputeUsesTheInputsOfTheSnapshot(t *testing.T) {
+	node := newFakeNode(t)
+	k, repo, cfg := newPipeline(t, node)
+
+	if err := repo.SetDelegation(runContext(), delegatorAddress, delegateAddress); err != nil {
+		t.Fatalf("SetDelegation() = %v", err)
+	}
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err != nil {
+		t.Fatalf("ProcessEvent() = %v", err)
+	}
+	parent, err := repo.ListSnapshotAccounts(runContext(), 1)
+	if err != nil {
+		t.Fatalf("ListSnapshotAccounts() = %v", err)
+	}
+
+	// Later changes of the tables must not reach the recomputed snapshot
+	if err := repo.RevokeDelegation(runContext(), delegatorAddress); err != nil {
+		t.Fatalf("RevokeDelegation() = %v", err)
+	}
+	if err := repo.SetDelegation(runContext(), laterAddress, delegateAddress); err != nil {
+		t.Fatalf("SetDelegation() = %v", err)
+	}
+	if err := repo.AddExclusion(runContext(), &models.Exclusion{Address: delegateAddress, Reason: "later"}, "token:ops"); err != nil {
+		t.Fatalf("AddExclusion() = %v", err)
+	}
+
+	snapshot, err := k.Recompute(runContext(), repo, cfg, 1, "")
+	if err != nil {
+		t.Fatalf("Recompute() = %v", err)
+	}
+	if got := lastSnapshot(t, repo, snapshot.ID); got.Status != models.SnapshotCompleted || got.ParentID == nil || *got.ParentID != 1 {
+		t.Errorf("recomputed snapshot = %+v, want a completed snapshot derived from 1", got)
+	}
+
+	// The same policy on the same inputs gives the same allocation
+	derived, err := repo.ListSnapshotAccounts(runContext(), snapshot.ID)
+	if err != nil {
+		t.Fatalf("ListSnapshotAccounts() = %v", err)
+	}
+	if len(derived) != len(parent) {
+		t.Fatalf("recomputed %d accounts, want %d", len(derived), len(parent))
+	}
+	for i := range parent {
+		if derived[i].Address != parent[i].Address || derived[i].Eligible != parent[i].Eligible ||
+			derived[i].Votes != parent[i].Votes || derived[i].EffectiveVotes != parent[i].EffectiveVotes {
+			t.Errorf("recomputed %+v, want %+v", derived[i], parent[i])
+		}
+		if parent[i].Address == delegatorAddress && parent[i].EffectiveVotes != 0 {
+			t.Errorf("delegator kept %v effective votes, want them handed over", parent[i].EffectiveVotes)
+		}
+	}
+
+	delegations, err := repo.ListSnapshotDelegations(runContext(), snapshot.ID)
+	if err != nil || len(delegations) != 1 || delegations[0].Delegator != delegatorAddress || delegations[0].Delegate != delegateAddress {
+		t.Errorf("ListSnapshotDelegations() = %+v, %v, want the delegation of the original run", delegations, err)
+	}
+	exclusions, err := repo.ListSnapshotExclusions(runContext(), snapshot.ID)
+	if err != nil || len(exclusions) != 1 || exclusions[0].Address != excludedAddress || exclusions[0].Reason != "test" {
+		t.Errorf("ListSnapshotExclusions() = %+v, %v, want the exclusion of the original run", exclusions, err)
+	}
+}
+
+func TestRecomputeRejectsAnIncompleteSnapshot(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{Errors: map[string]int{nodetest.AccountsAPI: http.StatusServiceUnavailable}})
+	k, repo, cfg := newPipeline(t, node)
+
+	if err := k.ProcessEvent(runContext(), &models.Data{}, repo, cfg); err == nil {
+		t.Fatal("ProcessEvent() = nil, want the node error")
+	}
+	if _, err := k.Recompute(runContext(), repo, cfg, 1, ""); !errors.Is(err, ErrSnapshotIncomplete) {
+		t.Errorf("Recompute() of a failed snapshot = %v, want %v", err, ErrSnapshotIncomplete)
+	}
+	if _, err := k.Recompute(runContext(), repo, cfg, 1, "missing"); err == nil {
+		t.Error("Recompute() with an unknown policy = nil, want an error")
+	}
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockRepo(ctrl)
+	k, _ := newTestClient(t)
+	cfg := models.DefaultConfig()
+
+	// Kept forever by default
+	k.pruneSnapshots(runContext(), repo, &cfg)
+
+	cfg.Run.SnapshotRetentionDays = 30
+	want := time.Now().AddDate(0, 0, -30)
+	repo.EXPECT().DeleteSnapshots(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, before time.Time) (int64, error) {
+		if d := before.Sub(want); d < -time.Minute || d > time.Minute {
+			t.Errorf("DeleteSnapshots(%v), want the snapshots finished before %v", before, want)
+		}
+		return 2, nil
+	})
+	k.pruneSnapshots(runContext(), repo, &cfg)
+
+	// A failure is only logged
+	repo.EXPECT().DeleteSnapshots(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("database is down"))
+	k.pruneSnapshots(runContext(), repo, &cfg)
+}
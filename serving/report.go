@@ -0,0 +1,24 @@
+package serving
+
+import (
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+// summarize - Count the accounts and the supply read from the node, and how much of it is excluded
+func summarize(report *models.RunReport, accounts []models.ChainAccount, unseatList models.Cached, total int) {
+	report.Accounts = len(accounts)
+	report.ScannedNdau = total
+	report.ExcludedNdauByClass = map[string]int{}
+
+	for _, account := range accounts {
+		if !account.Eligible {
+			report.ExcludedAccounts++
+			report.ExcludedNdau += account.Balance
+			report.ExcludedNdauByClass[account.Class] += account.Balance
+			continue
+		}
+		if _, unseated := unseatList[account.Address]; !unseated {
+			report.SeatedAccounts++
+		}
+	}
+}
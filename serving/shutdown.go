@@ -0,0 +1,54 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// shutdownTimeout bounds how long in-flight requests and runs get to finish once the server stops
+	shutdownTimeout = 30 * time.Second
+
+	// finishTimeout bounds the writes that record the outcome of a cancelled run
+	finishTimeout = 10 * time.Second
+)
+
+// sleep - Wait for d unless the context is cancelled first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// requestContext - The context of a request, also cancelled when the server shuts down
+func requestContext(server context.Context, r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	go func() {
+		select {
+		case <-server.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// detached - A context that outlives a cancelled one, with its tracking number, to record the outcome of a run
+func detached(ctx context.Context) (context.Context, context.CancelFunc) {
+	fresh := context.WithValue(context.Background(), "tracking_number", ctx.Value("tracking_number"))
+	return context.WithTimeout(fresh, finishTimeout)
+}
+
+// cancelled - Whether a run stopped because its context was cancelled
+func cancelled(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
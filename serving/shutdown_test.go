@@ -0,0 +1,132 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+)
+
+func TestSleep(t *testing.T) {
+	if err := sleep(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("sleep() = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleep() of a cancelled context = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("sleep() of a cancelled context took %v", elapsed)
+	}
+}
+
+func TestRequestContextEndsWithTheServer(t *testing.T) {
+	server, stop := context.WithCancel(context.Background())
+	ctx, cancel := requestContext(server, httptest.NewRequest("POST", "/", nil))
+	defer cancel()
+
+	stop()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the request context outlived the server")
+	}
+}
+
+func TestDetachedOutlivesTheRun(t *testing.T) {
+	run, cancel := context.WithCancel(runContext())
+	cancel()
+
+	ctx, done := detached(run)
+	defer done()
+	if ctx.Err() != nil {
+		t.Errorf("detached() = %v, want a live context", ctx.Err())
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("detached() has no deadline, want finishTimeout")
+	}
+	if got := ctx.Value("tracking_number"); got != "pipeline" {
+		t.Errorf("detached() tracking number = %v, want pipeline", got)
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tt := range []struct {
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{context.Background(), nil, false},
+		{context.Background(), errors.New("node error"), false},
+		{context.Background(), fmt.Errorf("batch: %w", context.Canceled), true},
+		{context.Background(), context.DeadlineExceeded, true},
+		{done, nil, true},
+	} {
+		if got := cancelled(tt.ctx, tt.err); got != tt.want {
+			t.Errorf("cancelled(%v, %v) = %t, want %t", tt.ctx.Err(), tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestProcessEventStopsBetweenBatches - A cancelled run does not wait for the pause between two batches
+func TestProcessEventStopsBetweenBatches(t *testing.T) {
+	node := newFakeNode(t)
+	k, repo, cfg := newPipeline(t, node)
+	cfg.Run.BatchPauseMs = int(time.Hour / time.Millisecond)
+
+	ctx, cancel := context.WithCancel(runContext())
+	go func() {
+		for node.Requests(nodetest.AccountsAPI) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	start := time.Now()
+	if err := k.ProcessEvent(ctx, &models.Data{}, repo, cfg); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ProcessEvent() = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("ProcessEvent() returned after %v", elapsed)
+	}
+	if snapshot := lastSnapshot(t, repo, 1); snapshot.Status != models.SnapshotCancelled || snapshot.FinishedAt == nil {
+		t.Errorf("snapshot = %+v, want a finished %s snapshot", snapshot, models.SnapshotCancelled)
+	}
+	if accounts, _ := repo.ListAccount(runContext()); len(accounts) != 0 {
+		t.Errorf("ListAccount() = %d accounts, want none written by a cancelled run", len(accounts))
+	}
+}
+
+// TestNodeRequestsFollowTheirContext - go-ndau builds its requests without the context, the client
+// attaches it so that a cancelled run does not wait for the node to answer
+func TestNodeRequestsFollowTheirContext(t *testing.T) {
+	node := newFakeNode(t)
+	node.SetFaults(nodetest.Faults{Latency: 5 * time.Second})
+	k, _, _ := newPipeline(t, node)
+
+	conn, err := k.nodeClient("testnet", node.URL)
+	if err != nil {
+		t.Fatalf("nodeClient() = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(runContext(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := conn.GetDataWithContext(ctx, nodetest.StatusAPI, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetDataWithContext() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetDataWithContext() returned after %v, want it stopped by its context", elapsed)
+	}
+}
@@ -0,0 +1,171 @@
+package serving
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrBadSignature is returned when no validation key of the account verifies the signature
+var ErrBadSignature = errors.New("signature does not match any validation key")
+
+// KeyVerifier - Check a signature against the validation keys of an account
+type KeyVerifier interface {
+	Verify(keys []string, message []byte, signature string) error
+}
+
+// Ed25519Verifier - Verify Ed25519 signatures. Keys are in the ndau text form the node answers (npub...),
+// signatures in the ndau text form or base64 serialized as in ndau transactions. Raw hex or base64
+// keys and signatures are accepted as well.
+type Ed25519Verifier struct{}
+
+// Verify - Succeed if any of the keys verifies the signature, keys of other algorithms are skipped
+func (Ed25519Verifier) Verify(keys []string, message []byte, signature string) error {
+	sig, err := parseSignature(signature)
+	if err != nil {
+		return fmt.Errorf("malformed signature")
+	}
+
+	for _, key := range keys {
+		pub, err := parsePublicKey(key)
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(pub, message, sig) {
+			return nil
+		}
+	}
+
+	return ErrBadSignature
+}
+
+const (
+	// ndauPublicKeyPrefix - The prefix of the text form of the ndau public keys
+	ndauPublicKeyPrefix = "npub"
+	// ndauEd25519 - The id of Ed25519 in the serialized ndau keys and signatures
+	ndauEd25519 = 1
+	// ndauAlphabet - The base32 alphabet of ndau keys and addresses, without the easily confused l, o, 0 and 1
+	ndauAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+)
+
+// parsePublicKey - Decode an Ed25519 public key, ndau text or raw hex or base64
+func parsePublicKey(s string) (ed25519.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, ndauPublicKeyPrefix) {
+		data, err := decodeNdauText(strings.TrimPrefix(s, ndauPublicKeyPrefix))
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseNdauKey(data, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(key), nil
+	}
+
+	key, err := decodeBytes(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("not an Ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// parseSignature - Decode an Ed25519 signature, ndau text, base64 of a serialized ndau signature or
+// raw hex or base64
+func parseSignature(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if sig, err := hex.DecodeString(s); err == nil && len(sig) == ed25519.SignatureSize {
+		return sig, nil
+	}
+	if data, err := decodeNdauText(s); err == nil {
+		if sig, err := parseNdauKey(data, ed25519.SignatureSize); err == nil {
+			return sig, nil
+		}
+	}
+
+	data, err := decodeBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == ed25519.SignatureSize {
+		return data, nil
+	}
+	return parseNdauKey(data, ed25519.SignatureSize)
+}
+
+// decodeNdauText - Decode the base32 text of an ndau key or signature and strip its checksum. The first
+// byte is the length of the checksum closing the data. The checksum itself is not verified: a damaged
+// key or signature fails the verification anyway.
+func decodeNdauText(s string) ([]byte, error) {
+	data := make([]byte, 0, len(s)*5/8)
+	var acc uint
+	bits := 0
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(ndauAlphabet, s[i])
+		if v < 0 {
+			return nil, fmt.Errorf("invalid character %q", s[i])
+		}
+		acc = acc<<5 | uint(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			data = append(data, byte(acc>>uint(bits)))
+			acc &= 1<<uint(bits) - 1
+		}
+	}
+	if acc != 0 {
+		return nil, fmt.Errorf("trailing bits")
+	}
+
+	if len(data) == 0 || int(data[0]) >= len(data) {
+		return nil, fmt.Errorf("no checksum")
+	}
+	return data[1 : len(data)-int(data[0])], nil
+}
+
+// parseNdauKey - The bytes of a serialized Ed25519 ndau key or signature: a msgpack array of the
+// algorithm id, the key and optional extra data
+func parseNdauKey(data []byte, size int) ([]byte, error) {
+	if len(data) < 2 || data[0]&0xf0 != 0x90 {
+		return nil, fmt.Errorf("not a serialized ndau key")
+	}
+	if n := int(data[0] & 0x0f); n < 2 || n > 3 {
+		return nil, fmt.Errorf("not a serialized ndau key")
+	}
+	if data[1] != ndauEd25519 {
+		return nil, fmt.Errorf("algorithm %d is not Ed25519", data[1])
+	}
+
+	// The key is a msgpack bin 8 or 16
+	rest := data[2:]
+	var n, header int
+	switch {
+	case len(rest) >= 2 && rest[0] == 0xc4:
+		n, header = int(rest[1]), 2
+	case len(rest) >= 3 && rest[0] == 0xc5:
+		n, header = int(rest[1])<<8|int(rest[2]), 3
+	default:
+		return nil, fmt.Errorf("not a serialized ndau key")
+	}
+	if n != size || len(rest) < header+n {
+		return nil, fmt.Errorf("key of %d bytes, want %d", n, size)
+	}
+	return rest[header : header+n], nil
+}
+
+// decodeBytes - Decode a hex, base64 or base64url string
+func decodeBytes(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("not a hex or base64 string")
+}
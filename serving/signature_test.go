@@ -0,0 +1,142 @@
+package serving
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testKey - A deterministic ndau keypair
+func testKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed([]byte(strings.Repeat(string(rune('a'+seed)), ed25519.SeedSize)))
+}
+
+// ndauSerialized - A key or signature serialized as ndau does, the msgpack array of the algorithm
+// id and the bytes
+func ndauSerialized(algorithm byte, b []byte) []byte {
+	return append([]byte{0x92, algorithm, 0xc4, byte(len(b))}, b...)
+}
+
+// ndauText - The base32 text of serialized data, checksum included
+func ndauText(data []byte) string {
+	// At least 4 checksum bytes, so that the text has no padding
+	ck := 4
+	for (1+len(data)+ck)%5 != 0 {
+		ck++
+	}
+	sum := sha256.Sum256(data)
+	buf := append(append([]byte{byte(ck)}, data...), sum[:ck]...)
+
+	var out strings.Builder
+	var acc uint
+	bits := 0
+	for _, b := range buf {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out.WriteByte(ndauAlphabet[acc>>uint(bits)&31])
+		}
+	}
+	return out.String()
+}
+
+// ndauPublicKey - The text form of a public key, as answered by the node
+func ndauPublicKey(key ed25519.PrivateKey) string {
+	return ndauPublicKeyPrefix + ndauText(ndauSerialized(ndauEd25519, key.Public().(ed25519.PublicKey)))
+}
+
+// ndauSignature - The text form of a signature
+func ndauSignature(key ed25519.PrivateKey, message []byte) string {
+	return ndauText(ndauSerialized(ndauEd25519, ed25519.Sign(key, message)))
+}
+
+func TestVerifyAcceptsNdauKeys(t *testing.T) {
+	key := testKey(1)
+	pub := key.Public().(ed25519.PublicKey)
+	message := []byte("ndau-dao-vote:mainnet:1:ndaaaddress:yes:1600000000")
+	sig := ed25519.Sign(key, message)
+
+	tests := []struct {
+		name      string
+		key       string
+		signature string
+	}{
+		{"ndau text", ndauPublicKey(key), ndauSignature(key, message)},
+		{"serialized signature in base64", ndauPublicKey(key), base64.StdEncoding.EncodeToString(ndauSerialized(ndauEd25519, sig))},
+		{"raw hex", hex.EncodeToString(pub), hex.EncodeToString(sig)},
+		{"raw base64", base64.StdEncoding.EncodeToString(pub), base64.RawURLEncoding.EncodeToString(sig)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (Ed25519Verifier{}).Verify([]string{tt.key}, message, tt.signature); err != nil {
+				t.Errorf("Verify() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	key, other := testKey(1), testKey(2)
+	message := []byte("ndau-dao-vote:mainnet:1:ndaaaddress:yes:1600000000")
+	secp256k1 := ndauPublicKeyPrefix + ndauText(ndauSerialized(2, key.Public().(ed25519.PublicKey)))
+
+	tests := []struct {
+		name      string
+		keys      []string
+		signature string
+		want      error
+	}{
+		{"signature of another message", []string{ndauPublicKey(key)}, ndauSignature(key, []byte("ndau-dao-vote:mainnet:1:ndaaaddress:no:1600000000")), ErrBadSignature},
+		{"unknown key", []string{ndauPublicKey(other)}, ndauSignature(key, message), ErrBadSignature},
+		{"no keys", nil, ndauSignature(key, message), ErrBadSignature},
+		{"key of another algorithm", []string{secp256k1}, ndauSignature(key, message), ErrBadSignature},
+		{"malformed key", []string{"npubnotakey"}, ndauSignature(key, message), ErrBadSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (Ed25519Verifier{}).Verify(tt.keys, message, tt.signature); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if err := (Ed25519Verifier{}).Verify([]string{ndauPublicKey(key)}, message, "not a signature"); err == nil || errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify() of a malformed signature = %v, want malformed signature", err)
+	}
+}
+
+func TestVerifyTriesEveryKey(t *testing.T) {
+	key := testKey(1)
+	message := []byte("message")
+	keys := []string{"npubnotakey", ndauPublicKey(testKey(2)), ndauPublicKey(key)}
+
+	if err := (Ed25519Verifier{}).Verify(keys, message, ndauSignature(key, message)); err != nil {
+		t.Errorf("Verify() = %v, want the third key to verify", err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key := testKey(3)
+	want := key.Public().(ed25519.PublicKey)
+
+	got, err := parsePublicKey(ndauPublicKey(key))
+	if err != nil {
+		t.Fatalf("parsePublicKey() = %v", err)
+	}
+	if !want.Equal(got) {
+		t.Errorf("parsePublicKey() = %x, want %x", got, want)
+	}
+
+	// A key cut short or with characters outside of the ndau alphabet
+	text := ndauPublicKey(key)
+	for _, bad := range []string{text[:len(text)-8], text[:10] + "0" + text[11:], ndauPublicKeyPrefix} {
+		if _, err := parsePublicKey(bad); err == nil {
+			t.Errorf("parsePublicKey(%q) = nil, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/ndau/dao-voting-setup/serving"
+
+	// trackingNumberKey links a trace to the logs of a run
+	trackingNumberKey = attribute.Key("tracking_number")
+)
+
+var tracer = otel.Tracer(tracerName)
+
+// InitTracing - Export the spans through OTLP when tracing is enabled, otherwise they are dropped.
+// The returned function flushes the pending spans.
+func InitTracing(ctx context.Context, cfg models.TracingConfig, log logger.Logger) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	log.Infof("Exporting traces to %s", cfg.Endpoint)
+	return provider.Shutdown, nil
+}
+
+// traced - Start a server span for each incoming request, continuing the trace of the caller
+func traced(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, r.URL.Path),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPMethodKey.String(r.Method), semconv.HTTPTargetKey.String(r.URL.Path)),
+		)
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// startSpan - Start a span of a run, tagged with its tracking number
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	trackingNumber, _ := ctx.Value("tracking_number").(string)
+	attrs = append(attrs, trackingNumberKey.String(trackingNumber))
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan - End a span, recording the error if any
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceID - The trace of a context, to be logged with the tracking number
+func traceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// nodeCall - Call the node API within a span named after the endpoint
+func nodeCall(ctx context.Context, api string, call func(ctx context.Context) ([]byte, error), attrs ...attribute.KeyValue) ([]byte, error) {
+	ctx, span := startSpan(ctx, "node "+api, append(attrs, attribute.String("node.api", api))...)
+	res, err := call(ctx)
+	endSpan(span, err)
+	return res, err
+}
@@ -0,0 +1,115 @@
+package serving
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ndau/dao-voting-setup/models"
+	logger "github.com/ndau/go-logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder - Record the spans of the package tracer. The global provider can only be delegated to
+// once, every test shares the recorder and looks for its own spans.
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return recorder
+}
+
+// spansOf - The ended spans of a trace
+func spansOf(traceID trace.TraceID) []sdktrace.ReadOnlySpan {
+	spans := []sdktrace.ReadOnlySpan{}
+	for _, span := range spanRecorder().Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestInitTracingDisabled(t *testing.T) {
+	shutdown, err := InitTracing(context.Background(), models.TracingConfig{}, &logger.NoopLogger{})
+	if err != nil {
+		t.Fatalf("InitTracing() = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() = %v", err)
+	}
+}
+
+func TestTracedContinuesTheTraceOfTheCaller(t *testing.T) {
+	spanRecorder()
+	var served trace.SpanContext
+	server := httptest.NewServer(traced(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = trace.SpanContextFromContext(r.Context())
+	})))
+	defer server.Close()
+
+	// A trace of its own, the recorder keeps the spans of the previous runs of the test
+	var traceID trace.TraceID
+	rand.Read(traceID[:])
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/stats", nil)
+	req.Header.Set("traceparent", "00-"+traceID.String()+"-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /stats = %v", err)
+	}
+	resp.Body.Close()
+
+	if served.TraceID() != traceID {
+		t.Errorf("request trace = %s, want the trace of the caller %s", served.TraceID(), traceID)
+	}
+	spans := spansOf(traceID)
+	if len(spans) != 1 || spans[0].Name() != "GET /stats" || spans[0].SpanKind() != trace.SpanKindServer {
+		t.Fatalf("spans = %v, want a GET /stats server span", spans)
+	}
+	if spans[0].Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("parent = %s, want the span of the caller", spans[0].Parent().SpanID())
+	}
+}
+
+func TestProcessEventSpans(t *testing.T) {
+	spanRecorder()
+	node := newFakeNode(t)
+	k, repo, cfg := newPipeline(t, node)
+
+	ctx, root := otel.Tracer("test").Start(runContext(), "test")
+	if err := k.ProcessEvent(ctx, &models.Data{}, repo, cfg); err != nil {
+		t.Fatalf("ProcessEvent() = %v", err)
+	}
+	root.End()
+
+	names := map[string]int{}
+	for _, span := range spansOf(root.SpanContext().TraceID()) {
+		names[span.Name()]++
+		if span.Name() == "ProcessEvent" {
+			tagged := false
+			for _, kv := range span.Attributes() {
+				tagged = tagged || (kv.Key == trackingNumberKey && kv.Value.AsString() == "pipeline")
+			}
+			if !tagged {
+				t.Errorf("ProcessEvent attributes = %v, want the tracking number", span.Attributes())
+			}
+		}
+	}
+	if names["ProcessEvent"] != 1 || names["node /price/current"] != 1 || names["node /account/list"] == 0 {
+		t.Errorf("spans = %v, want ProcessEvent and a span per node call", names)
+	}
+}
@@ -0,0 +1,153 @@
+package serving
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+)
+
+const (
+	// Signed votes older or further in the future than this are rejected
+	voteMaxSkew = 5 * time.Minute
+
+	maxVoteBodySize = 64 << 10
+)
+
+// accountDetail - The part of the node account data used by the service
+type accountDetail struct {
+	Balance          int          `json:"balance"`
+	CurrencySeatDate time.Time    `json:"currencySeatDate"`
+	ValidationKeys   []string     `json:"validationKeys"`
+	Lock             *accountLock `json:"lock"`
+}
+
+// castVote - POST /proposals/{id}/votes
+func (k *KnClient) castVote(w http.ResponseWriter, r *http.Request, repo dal.Repo, cfg *models.Config, proposalID int64) {
+	ctx := r.Context()
+
+	var req models.VoteRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxVoteBodySize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed vote")
+		return
+	}
+	switch req.Choice {
+	case models.ChoiceYes, models.ChoiceNo, models.ChoiceAbstain:
+	default:
+		writeError(w, http.StatusBadRequest, "choice must be 'yes', 'no' or 'abstain'")
+		return
+	}
+	if req.Address == "" || req.Signature == "" || req.Timestamp.IsZero() {
+		writeError(w, http.StatusBadRequest, "address, timestamp and signature are required")
+		return
+	}
+	castAt := time.Unix(req.Timestamp.Unix(), 0)
+	if skew := time.Since(castAt); skew > voteMaxSkew || skew < -voteMaxSkew {
+		writeError(w, http.StatusBadRequest, "vote timestamp is outside the accepted window")
+		return
+	}
+
+	proposal, err := repo.GetProposal(ctx, proposalID)
+	if errors.Is(err, dal.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "proposal not found")
+		return
+	} else if err != nil {
+		k.Log.Errorf("Failed to read proposal %d: %v", proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to read proposal")
+		return
+	}
+	if proposal.Status(time.Now()) != models.ProposalStatusOpen {
+		writeError(w, http.StatusConflict, "proposal is not open for voting")
+		return
+	}
+
+	account, err := repo.GetAccount(ctx, req.Address)
+	if err != nil && !errors.Is(err, dal.ErrNotFound) {
+		k.Log.Errorf("Failed to read account %s: %v", req.Address, err)
+		writeError(w, http.StatusInternalServerError, "failed to read account")
+		return
+	}
+	if account == nil || !account.Seated() {
+		writeError(w, http.StatusForbidden, "account does not hold a currency seat")
+		return
+	}
+
+	// Reject replays early, CastVote enforces it again atomically
+	existing, err := repo.GetVote(ctx, proposalID, req.Address)
+	if err != nil && !errors.Is(err, dal.ErrNotFound) {
+		k.Log.Errorf("Failed to read vote of %s on proposal %d: %v", req.Address, proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to read vote")
+		return
+	}
+	if existing != nil && !castAt.After(existing.CastAt) {
+		writeError(w, http.StatusConflict, "vote is not newer than the recorded one")
+		return
+	}
+
+	keys, err := k.validationKeys(ctx, cfg, req.Address)
+	if err != nil {
+		k.Log.Errorf("Failed to fetch validation keys of %s: %v", req.Address, err)
+		writeError(w, http.StatusBadGateway, "failed to fetch validation keys")
+		return
+	}
+	if err := k.Verifier.Verify(keys, req.SignedMessage(cfg.Network, proposalID), req.Signature); err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	vote := models.Vote{
+		ProposalID:  proposalID,
+		UserAddress: req.Address,
+		Choice:      req.Choice,
+		CastAt:      castAt,
+	}
+	switch err := repo.CastVote(ctx, &vote); {
+	case errors.Is(err, dal.ErrVoteFrozen), errors.Is(err, dal.ErrStaleVote):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		k.Log.Errorf("Failed to cast vote of %s on proposal %d: %v", req.Address, proposalID, err)
+		writeError(w, http.StatusInternalServerError, "failed to cast vote")
+		return
+	}
+
+	k.Log.Infof("Recorded '%s' vote of %s on proposal %d", vote.Choice, vote.UserAddress, proposalID)
+	writeJSON(w, http.StatusCreated, vote)
+}
+
+// errNoNodeAPI - The node API is needed but not configured
+var errNoNodeAPI = errors.New("no node API configured")
+
+// validationKeys - Read the validation keys of an account from the node API
+func (k *KnClient) validationKeys(ctx context.Context, cfg *models.Config, address string) ([]string, error) {
+	if cfg.NodeAPI == "" {
+		return nil, errNoNodeAPI
+	}
+
+	conn, err := k.nodeClient(cfg.Network, cfg.NodeAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := conn.GetDataWithContext(ctx, "/account/account/"+address, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r map[string]accountDetail
+	if err := json.Unmarshal(res, &r); err != nil {
+		return nil, err
+	}
+	detail, ok := r[address]
+	if !ok {
+		return nil, fmt.Errorf("account %s not found on the node", address)
+	}
+
+	return detail.ValidationKeys, nil
+}
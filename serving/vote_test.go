@@ -0,0 +1,159 @@
+package serving
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ndau/dao-voting-setup/configuration"
+	"github.com/ndau/dao-voting-setup/dal"
+	"github.com/ndau/dao-voting-setup/models"
+	"github.com/ndau/dao-voting-setup/serving/nodetest"
+)
+
+const (
+	voterAddress = "ndaavoter000000000000000000000000000000000000001"
+	// voteNetwork - The network of the vote server, part of the signed message
+	voteNetwork = "testnet"
+)
+
+// voteServer - The vote endpoint over an in-memory repository, the validation keys read from a fake node
+type voteServer struct {
+	*httptest.Server
+	repo   *dal.Memory
+	key    ed25519.PrivateKey
+	open   int64
+	closed int64
+}
+
+func newVoteServer(t *testing.T) *voteServer {
+	t.Helper()
+
+	key := testKey(1)
+	node := nodetest.NewServer(&nodetest.Fixture{Accounts: map[string]nodetest.Account{
+		voterAddress: {Balance: 100, ValidationKeys: []string{ndauPublicKey(testKey(2)), ndauPublicKey(key)}},
+	}})
+	t.Cleanup(node.Close)
+
+	cfg := models.DefaultConfig()
+	cfg.Network = voteNetwork
+	cfg.NodeAPI = node.URL
+	k, err := NewKnClient(&cfg)
+	if err != nil {
+		t.Fatalf("NewKnClient() = %v", err)
+	}
+
+	repo := dal.NewMemory()
+	repo.UpsertVotingList(runContext(), []models.VotingSetup{seatedAccount(voterAddress, 100, 1), {Address: "unseated"}})
+	open := models.Proposal{IsApproved: true, ClosingDate: time.Now().Add(time.Hour)}
+	closed := models.Proposal{IsApproved: true, ClosingDate: time.Now().Add(-time.Hour)}
+	for _, proposal := range []*models.Proposal{&open, &closed} {
+		if err := repo.CreateProposal(runContext(), proposal); err != nil {
+			t.Fatalf("CreateProposal() = %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	k.registerQueryRoutes(mux, repo, configuration.NewStore(&cfg))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return &voteServer{Server: server, repo: repo, key: key, open: open.ProposalID, closed: closed.ProposalID}
+}
+
+// signedVote - A vote signed with a key of the voter for the network of the server
+func (s *voteServer) signedVote(proposalID int64, choice string, at time.Time) models.VoteRequest {
+	req := models.VoteRequest{Address: voterAddress, Choice: choice, Timestamp: at}
+	req.Signature = ndauSignature(s.key, req.SignedMessage(voteNetwork, proposalID))
+	return req
+}
+
+// post - POST a vote, returning the status
+func (s *voteServer) post(t *testing.T, proposalID int64, req models.VoteRequest) int {
+	t.Helper()
+
+	body, _ := json.Marshal(req)
+	resp, err := http.Post(fmt.Sprintf("%s/proposals/%d/votes", s.URL, proposalID), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST vote = %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestCastVote(t *testing.T) {
+	s := newVoteServer(t)
+	now := time.Now().Truncate(time.Second)
+
+	if status := s.post(t, s.open, s.signedVote(s.open, models.ChoiceYes, now.Add(-time.Minute))); status != http.StatusCreated {
+		t.Fatalf("POST vote = %d, want %d", status, http.StatusCreated)
+	}
+	vote, err := s.repo.GetVote(runContext(), s.open, voterAddress)
+	if err != nil {
+		t.Fatalf("GetVote() = %v", err)
+	}
+	if vote.Choice != models.ChoiceYes || !vote.CastAt.Equal(now.Add(-time.Minute)) {
+		t.Errorf("recorded vote = %+v, want yes cast a minute ago", vote)
+	}
+
+	// A newer vote changes the choice, a replay of the first one is rejected
+	if status := s.post(t, s.open, s.signedVote(s.open, models.ChoiceNo, now)); status != http.StatusCreated {
+		t.Errorf("POST newer vote = %d, want %d", status, http.StatusCreated)
+	}
+	if status := s.post(t, s.open, s.signedVote(s.open, models.ChoiceYes, now.Add(-time.Minute))); status != http.StatusConflict {
+		t.Errorf("POST replayed vote = %d, want %d", status, http.StatusConflict)
+	}
+	if vote, err := s.repo.GetVote(runContext(), s.open, voterAddress); err != nil || vote.Choice != models.ChoiceNo {
+		t.Errorf("recorded vote = %+v, %v, want no", vote, err)
+	}
+}
+
+func TestCastVoteRejects(t *testing.T) {
+	s := newVoteServer(t)
+	now := time.Now()
+
+	badSignature := s.signedVote(s.open, models.ChoiceYes, now)
+	badSignature.Choice = models.ChoiceNo
+	unknownKey := s.signedVote(s.open, models.ChoiceYes, now)
+	unknownKey.Signature = ndauSignature(testKey(3), unknownKey.SignedMessage(voteNetwork, s.open))
+	otherNetwork := s.signedVote(s.open, models.ChoiceYes, now)
+	otherNetwork.Signature = ndauSignature(s.key, otherNetwork.SignedMessage("mainnet", s.open))
+	otherProposal := s.signedVote(s.closed, models.ChoiceYes, now)
+	unseated := s.signedVote(s.open, models.ChoiceYes, now)
+	unseated.Address = "unseated"
+	malformed := s.signedVote(s.open, models.ChoiceYes, now)
+	malformed.Signature = "not a signature"
+
+	tests := []struct {
+		name       string
+		proposalID int64
+		req        models.VoteRequest
+		want       int
+	}{
+		{"signature of another choice", s.open, badSignature, http.StatusUnauthorized},
+		{"unknown key", s.open, unknownKey, http.StatusUnauthorized},
+		{"signature of another proposal", s.open, otherProposal, http.StatusUnauthorized},
+		{"signature of another network", s.open, otherNetwork, http.StatusUnauthorized},
+		{"malformed signature", s.open, malformed, http.StatusUnauthorized},
+		{"closed proposal", s.closed, s.signedVote(s.closed, models.ChoiceYes, now), http.StatusConflict},
+		{"missing proposal", 999, s.signedVote(999, models.ChoiceYes, now), http.StatusNotFound},
+		{"unseated account", s.open, unseated, http.StatusForbidden},
+		{"stale timestamp", s.open, s.signedVote(s.open, models.ChoiceYes, now.Add(-time.Hour)), http.StatusBadRequest},
+		{"unknown choice", s.open, s.signedVote(s.open, "maybe", now), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := s.post(t, tt.proposalID, tt.req); status != tt.want {
+				t.Errorf("POST vote = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	if votes, err := s.repo.ListVotes(runContext(), s.open); err != nil || len(votes) != 0 {
+		t.Errorf("ListVotes() = %v, %v, want no vote recorded", votes, err)
+	}
+}